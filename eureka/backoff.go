@@ -0,0 +1,56 @@
+package eureka
+
+import (
+    "math/rand"
+    "time"
+)
+
+// exponential backoff with jitter for retry loops,
+// delay doubles on each consecutive failure, up to base * bound
+// Refer to: Netflix's TimedSupervisorTask
+type backoff struct {
+    // delay after the first failure
+    base time.Duration
+
+    // max multiplier of base
+    bound int
+
+    // consecutive failures
+    failures int
+}
+
+func newBackoff(base time.Duration, bound int) *backoff {
+    if bound < 1 {
+        bound = 1
+    }
+
+    return &backoff{
+        base:  base,
+        bound: bound,
+    }
+}
+
+// delay before next retry, jitter picks a random delay in [delay/2, delay]
+func (t *backoff) next() time.Duration {
+    multiplier := 1
+    for i := 0; i < t.failures && multiplier < t.bound; i++ {
+        multiplier *= 2
+    }
+    if multiplier > t.bound {
+        multiplier = t.bound
+    }
+    t.failures++
+
+    delay := t.base * time.Duration(multiplier)
+    half := int64(delay / 2)
+    if half <= 0 {
+        return delay
+    }
+
+    return time.Duration(half + rand.Int63n(half+1))
+}
+
+// reset after success
+func (t *backoff) reset() {
+    t.failures = 0
+}
@@ -0,0 +1,26 @@
+package eureka
+
+import (
+    "testing"
+    "time"
+)
+
+func Test_Backoff(t *testing.T) {
+    retry := newBackoff(time.Second, 10)
+
+    // 1s, 2s, 4s, 8s, then bounded by 10s
+    expected := []time.Duration{1, 2, 4, 8, 10, 10}
+    for i, max := range expected {
+        max = max * time.Second
+        delay := retry.next()
+        if delay < max/2 || delay > max {
+            t.Fatalf("Retry %d: expected delay in [%s, %s], got %s", i, max/2, max, delay)
+        }
+    }
+
+    // reset after success
+    retry.reset()
+    if delay := retry.next(); delay > time.Second {
+        t.Fatalf("Expected delay reset to at most 1s, got %s", delay)
+    }
+}
@@ -0,0 +1,96 @@
+package eureka
+
+import (
+    "encoding/json"
+    "fmt"
+    "io/ioutil"
+    "os"
+    "path/filepath"
+    "time"
+)
+
+const (
+    DEFAULT_BACKUP_REGISTRY_INTERVAL_SECONDS = 60
+)
+
+// fall back registry, snapshot of registry is saved periodically,
+// and loaded only while registry has never been fetched from eureka server, e.g: eureka server unreachable on startup.
+// Refer to: Netflix's BackupRegistry
+type BackupRegistry interface {
+    // save snapshot of registry apps
+    Save(apps map[string]ApplicationVo) error
+
+    // load last snapshot of registry apps, and the time it was taken
+    Load() (map[string]ApplicationVo, time.Time, error)
+}
+
+// BackupRegistry kept in a local (json) file
+type FileBackupRegistry struct {
+    Path string
+}
+
+// snapshot file content
+type registrySnapshot struct {
+    // unix timestamp in milliseconds
+    Timestamp    Timestamp       `json:"timestamp"`
+    Applications []ApplicationVo `json:"applications"`
+}
+
+func NewFileBackupRegistry(path string) *FileBackupRegistry {
+    return &FileBackupRegistry{Path: path}
+}
+
+// write snapshot into a temp file then rename it, so that a partial snapshot is never loaded
+func (t *FileBackupRegistry) Save(apps map[string]ApplicationVo) error {
+    snapshot := registrySnapshot{
+        Timestamp:    Timestamp(time.Now().UnixNano() / int64(time.Millisecond)),
+        Applications: make([]ApplicationVo, 0, len(apps)),
+    }
+    for _, app := range apps {
+        snapshot.Applications = append(snapshot.Applications, app)
+    }
+
+    data, err := json.Marshal(snapshot)
+    if err != nil {
+        return err
+    }
+
+    tmp, err := ioutil.TempFile(filepath.Dir(t.Path), filepath.Base(t.Path)+".tmp")
+    if err != nil {
+        return err
+    }
+    _, err = tmp.Write(data)
+    if closeErr := tmp.Close(); err == nil {
+        err = closeErr
+    }
+    if err != nil {
+        os.Remove(tmp.Name())
+        return err
+    }
+
+    err = os.Rename(tmp.Name(), t.Path)
+    if err != nil {
+        os.Remove(tmp.Name())
+    }
+    return err
+}
+
+func (t *FileBackupRegistry) Load() (map[string]ApplicationVo, time.Time, error) {
+    data, err := ioutil.ReadFile(t.Path)
+    if err != nil {
+        return nil, time.Time{}, err
+    }
+
+    snapshot := registrySnapshot{}
+    err = json.Unmarshal(data, &snapshot)
+    if err != nil {
+        return nil, time.Time{}, fmt.Errorf("Failed to decode backup registry file=%s, err=%w", t.Path, err)
+    }
+
+    apps := make(map[string]ApplicationVo, len(snapshot.Applications))
+    for _, app := range snapshot.Applications {
+        apps[app.Name] = app
+    }
+
+    return apps, snapshot.Timestamp.Time(), nil
+}
@@ -0,0 +1,40 @@
+package eureka
+
+import (
+    "path/filepath"
+    "reflect"
+    "testing"
+    "time"
+)
+
+func Test_FileBackupRegistry(t *testing.T) {
+    backupRegistry := NewFileBackupRegistry(filepath.Join(t.TempDir(), "registry.json"))
+    if _, _, err := backupRegistry.Load(); err == nil {
+        t.Fatal("Loading missing backup registry should fail")
+    }
+
+    ins := DefaultInstanceVo()
+    ins.App = "TEST-APP"
+    ins.InstanceId = "10.0.0.1:test-app:8080"
+    ins.Metadata = map[string]string{"version": "1.0"}
+    apps := map[string]ApplicationVo{
+        "TEST-APP": {Name: "TEST-APP", Instances: []InstanceVo{*ins}},
+    }
+
+    start := time.Now().Add(-time.Second)
+    err := backupRegistry.Save(apps)
+    if err != nil {
+        t.Fatal(err.Error())
+    }
+
+    loaded, snapshotTime, err := backupRegistry.Load()
+    if err != nil {
+        t.Fatal(err.Error())
+    }
+    if !reflect.DeepEqual(apps, loaded) {
+        t.Fatalf("Expected %+v, got %+v", apps, loaded)
+    }
+    if snapshotTime.Before(start) || snapshotTime.After(time.Now()) {
+        t.Fatalf("Unexpected snapshot time: %s", snapshotTime)
+    }
+}
@@ -1,8 +1,11 @@
 package eureka
 
 import (
+    "context"
     "errors"
+    "fmt"
     "math/rand"
+    "net/http"
     "os"
     "os/signal"
     "strings"
@@ -12,7 +15,11 @@ import (
 )
 
 const (
-    DEFAULT_SLEEP_INTERVALS = 3
+    DEFAULT_SLEEP_INTERVALS  = 3
+    DEFAULT_SHUTDOWN_TIMEOUT = 30
+
+    // timeout of de-registering while ctx of Shutdown() is done
+    DEFAULT_UNREGISTER_TIMEOUT = 5
 )
 
 var DefaultClient = new(Client)
@@ -25,6 +32,9 @@ type Client struct {
     // current client (instance) config
     instance *InstanceVo
 
+    // error of building instance by RegisterConfig(), returned by Run()
+    instanceErr error
+
     // eureka server base url list
     serviceUrls []string
 
@@ -33,9 +43,60 @@ type Client struct {
     // value: ApplicationVo
     registryApps map[string]ApplicationVo
 
+    // when registryApps was fetched (or snapshot was taken, while loaded from backup registry)
+    registryTime time.Time
+
+    // whether registryApps is loaded from backup registry, and not fetched from eureka server yet
+    registryFromBackup bool
+
+    // fall back registry, refer to BackupRegistry()
+    backupRegistry BackupRegistry
+
+    // registry apps of remote regions, refer to FetchRemoteRegionsRegistry
+    // key: region
+    // value: registry apps, key: appId
+    remoteRegistryApps map[string]map[string]ApplicationVo
+
+    // EurekaServerApi with failover across service urls of remote regions
+    // key: region
+    remoteApis map[string]*RetryableEurekaServerApi
+
+    // EurekaServerApi with failover across service urls, refer to RetryableApi()
+    retryableApi *RetryableEurekaServerApi
+
+    // http client to talk to eureka server, refer to HttpClient()
+    httpClient *http.Client
+
+    // strategy to choose instance, refer to ChooseInstance()
+    lb LoadBalancer
+
+    // health check driving instance status, refer to HealthCheckHandler()
+    healthCheckHandler HealthCheckHandler
+
+    // metadata declared before instance registered, refer to Metadata()
+    metadata map[string]string
+
+    // metrics hook, refer to Metrics()
+    metrics Metrics
+
+    // registry change watchers and listeners, refer to Watch()
+    watchers  map[*registryWatcher]bool
+    listeners []RegistryListener
+    watchMu   sync.Mutex
+
     // for monitor system signal
     signalChan chan os.Signal
 
+    // whether instance is registered to eureka server
+    registered bool
+
+    // client lifecycle, cancel to stop heartbeat, registry refresh
+    // and dns refresh goroutines
+    ctx      context.Context
+    cancel   context.CancelFunc
+    wg       sync.WaitGroup
+    stopOnce sync.Once
+
     mu sync.RWMutex
 }
 
@@ -44,24 +105,144 @@ func (t *Client) Config(config *EurekaClientConfig) *Client {
     return t
 }
 
+// report metrics of heartbeat, register, registry fetch, service url refresh
+// and requests to eureka server, e.g: NewPrometheusMetrics()
+func (t *Client) Metrics(metrics Metrics) *Client {
+    t.metrics = metrics
+    return t
+}
+
+// snapshot registry into backup registry periodically, and bootstrap registry from it
+// only while registry has never been fetched, default: FileBackupRegistry of BackupRegistryFile (if set)
+func (t *Client) BackupRegistry(backupRegistry BackupRegistry) *Client {
+    t.backupRegistry = backupRegistry
+    return t
+}
+
 // user brief parameters to register instance
 func (t *Client) Register(appId string, port int) *Client {
-    vo := DefaultInstanceVo()
-    vo.App = appId
-    vo.Status = STATUS_STARTING
-    vo.Port = positiveInt{Value: port, Enabled: "true"}
-    vo.VipAddress = strings.ToLower(appId)
-    vo.SecureVipAddress = strings.ToLower(appId)
-    t.instance = vo
-    return t
+    config := GetDefaultEurekaInstanceConfig()
+    config.Appname = appId
+    config.NonSecurePort = port
+    return t.RegisterConfig(config)
+}
+
+// user instance config to register instance
+func (t *Client) RegisterConfig(config *EurekaInstanceConfig) *Client {
+    vo, err := config.InstanceVo()
+    if err != nil {
+        log.Errorf("Failed to build instance from config, err=%s", err.Error())
+        t.instance = nil
+        t.instanceErr = err
+        return t
+    }
+    return t.RegisterVo(vo)
 }
 
 // user raw instanceVo to register instance
 func (t *Client) RegisterVo(vo *InstanceVo) *Client {
+    if len(t.metadata) > 0 {
+        vo.Metadata = mergeMetadata(vo.Metadata, t.metadata)
+    }
     t.instance = vo
+    t.instanceErr = nil
+    return t
+}
+
+// metadata of instance registered with, e.g: version, git sha, canary flag,
+// update it at runtime by SetMetadata()
+func (t *Client) Metadata(metadata map[string]string) *Client {
+    t.metadata = metadata
+    if t.instance != nil {
+        t.instance.Metadata = mergeMetadata(t.instance.Metadata, metadata)
+    }
+    return t
+}
+
+// http client to talk to eureka server, e.g: with custom transport / TLS config,
+// default: built from config (TLS*, EurekaServer*TimeoutSeconds, EurekaServerTotalConnections*, EurekaConnectionIdleTimeoutSeconds),
+// shared by all requests of client
+func (t *Client) HttpClient(httpClient *http.Client) *Client {
+    t.httpClient = httpClient
     return t
 }
 
+// load balancer to choose instance, default: round robin
+func (t *Client) LoadBalancer(lb LoadBalancer) *Client {
+    t.lb = lb
+    return t
+}
+
+// health check handler polled every InstanceInfoReplicationIntervalSeconds,
+// instance status in eureka server follows its result, default: always UP
+func (t *Client) HealthCheckHandler(handler HealthCheckHandler) *Client {
+    t.healthCheckHandler = handler
+    return t
+}
+
+// update metadata of instance, merged into current metadata,
+// pushed to eureka server while instance is registered, otherwise registered with.
+// local metadata is kept unchanged while pushing failed
+func (t *Client) SetMetadata(metadata map[string]string) error {
+    if t.instance == nil {
+        return errors.New("Eureka instance can't be nil")
+    }
+
+    t.mu.Lock()
+    registered := t.registered
+    if !registered {
+        t.instance.Metadata = mergeMetadata(t.instance.Metadata, metadata)
+    }
+    t.mu.Unlock()
+    if !registered {
+        return nil
+    }
+
+    api, err := t.RetryableApi()
+    if err != nil {
+        log.Errorf("Failed to update metadata, err=%s", err.Error())
+        return err
+    }
+
+    err = api.DoContext(t.context(), func(api *EurekaServerApi) error {
+        return api.UpdateMetaContext(t.context(), t.instance.App, t.instance.InstanceId, metadata)
+    })
+    if err != nil {
+        log.Errorf("Failed to update metadata, err=%s", err.Error())
+        return err
+    }
+
+    t.mu.Lock()
+    t.instance.Metadata = mergeMetadata(t.instance.Metadata, metadata)
+    t.mu.Unlock()
+    return nil
+}
+
+// copy of metadata merged with updates, current metadata is never modified
+func mergeMetadata(metadata, updates map[string]string) InstanceMetadata {
+    merged := InstanceMetadata{}
+    for k, v := range metadata {
+        merged[k] = v
+    }
+    for k, v := range updates {
+        merged[k] = v
+    }
+
+    return merged
+}
+
+// metadata of current whose value differs from (or missing in) registered
+func changedMetadata(registered, current map[string]string) map[string]string {
+    changed := map[string]string{}
+    for k, v := range current {
+        if old, ok := registered[k]; !ok || old != v {
+            changed[k] = v
+        }
+    }
+
+    return changed
+}
+
 // Api for sending rest http to eureka server
 func (t *Client) Api() (*EurekaServerApi, error) {
     api, err := t.pickEurekaServerApi()
@@ -83,24 +264,356 @@ func (t *Client) GetRegistryApps() map[string]ApplicationVo {
     return t.registryApps
 }
 
+// age of local registry, since it was fetched from eureka server
+// (or since snapshot was taken, while loaded from backup registry),
+// ok is false while registry is neither fetched nor loaded yet
+func (t *Client) GetRegistryAge() (age time.Duration, ok bool) {
+    t.mu.RLock()
+    defer t.mu.RUnlock()
+
+    if t.registryApps == nil {
+        return 0, false
+    }
+    return time.Since(t.registryTime), true
+}
+
+// whether local registry is loaded from backup registry, and not fetched from eureka server yet
+func (t *Client) IsRegistryFromBackup() bool {
+    t.mu.RLock()
+    defer t.mu.RUnlock()
+
+    return t.registryFromBackup
+}
+
+// get app's instances from local registry,
+// (if FilterOnlyUpInstances is true) only UP instances returned
+func (t *Client) GetInstancesByAppId(appId string) []InstanceVo {
+    t.mu.RLock()
+    defer t.mu.RUnlock()
+
+    return t.appInstances(make([]InstanceVo, 0), t.registryApps, appId)
+}
+
+// get app's instances from registry of region, the local region (Region) or one of FetchRemoteRegionsRegistry,
+// (if FilterOnlyUpInstances is true) only UP instances returned
+func (t *Client) GetInstancesByAppIdAndRegion(appId, region string) []InstanceVo {
+    t.mu.RLock()
+    defer t.mu.RUnlock()
+
+    return t.appInstances(make([]InstanceVo, 0), t.regionRegistryApps(region), appId)
+}
+
+// get app's instances from local registry, and from registries of remote regions while includeRemoteRegions is true,
+// local instances first, then remote regions in order of FetchRemoteRegionsRegistry
+func (t *Client) GetAllInstancesByAppId(appId string, includeRemoteRegions bool) []InstanceVo {
+    t.mu.RLock()
+    defer t.mu.RUnlock()
+
+    instances := t.appInstances(make([]InstanceVo, 0), t.registryApps, appId)
+    if !includeRemoteRegions {
+        return instances
+    }
+
+    for _, region := range t.config.GetRemoteRegions() {
+        instances = t.appInstances(instances, t.remoteRegistryApps[region], appId)
+    }
+    return instances
+}
+
+// registry apps of region, the local region (Region) or one of FetchRemoteRegionsRegistry
+func (t *Client) GetRegionRegistryApps(region string) map[string]ApplicationVo {
+    t.mu.RLock()
+    defer t.mu.RUnlock()
+
+    return t.regionRegistryApps(region)
+}
+
+// (locked by caller)
+func (t *Client) regionRegistryApps(region string) map[string]ApplicationVo {
+    region = strings.ToLower(region)
+    if region == t.config.GetRegion() {
+        return t.registryApps
+    }
+
+    return t.remoteRegistryApps[region]
+}
+
+// append app's instances in registry apps,
+// (if FilterOnlyUpInstances is true) only UP instances
+func (t *Client) appInstances(instances []InstanceVo, apps map[string]ApplicationVo, appId string) []InstanceVo {
+    app, ok := apps[strings.ToUpper(appId)]
+    if !ok {
+        return instances
+    }
+
+    for _, ins := range app.Instances {
+        if t.config.FilterOnlyUpInstances && ins.Status != STATUS_UP {
+            continue
+        }
+        instances = append(instances, ins)
+    }
+
+    return instances
+}
+
+// get instances by vip address (or secure vip address while secure is true) from local registry,
+// (if FilterOnlyUpInstances is true) only UP instances returned
+func (t *Client) GetInstancesByVipAddress(vipAddress string, secure bool) []InstanceVo {
+    t.mu.RLock()
+    defer t.mu.RUnlock()
+
+    instances := make([]InstanceVo, 0)
+    for _, app := range t.registryApps {
+        for _, ins := range app.Instances {
+            if t.config.FilterOnlyUpInstances && ins.Status != STATUS_UP {
+                continue
+            }
+
+            addresses := ins.VipAddress
+            if secure {
+                addresses = ins.SecureVipAddress
+            }
+            if matchVipAddress(addresses, vipAddress) {
+                instances = append(instances, ins)
+            }
+        }
+    }
+
+    return instances
+}
+
+// vip addresses are comma separated and case insensitive, e.g: app-a,app-a-v2
+func matchVipAddress(addresses, vipAddress string) bool {
+    for _, address := range strings.Split(addresses, ",") {
+        if strings.EqualFold(strings.TrimSpace(address), vipAddress) {
+            return true
+        }
+    }
+
+    return false
+}
+
+// choose one instance of app from local registry by load balancer
+func (t *Client) ChooseInstance(appId string) (*InstanceVo, error) {
+    instances := t.GetInstancesByAppId(appId)
+    if len(instances) == 0 {
+        return nil, fmt.Errorf("%w, app=%s", ErrNoInstance, appId)
+    }
+
+    t.mu.Lock()
+    if t.lb == nil {
+        t.lb = NewRoundRobinLoadBalancer()
+    }
+    lb := t.lb
+    t.mu.Unlock()
+
+    chosen := lb.Choose(strings.ToUpper(appId), instances)
+    if chosen == nil {
+        return nil, fmt.Errorf("%w, app=%s, load balancer chose nil", ErrNoInstance, appId)
+    }
+    ins := *chosen
+    return &ins, nil
+}
+
+// choose one instance of app and return its base url, e.g: http://192.168.20.1:8080
+func (t *Client) ChooseInstanceUrl(appId string) (string, error) {
+    ins, err := t.ChooseInstance(appId)
+    if err != nil {
+        return "", err
+    }
+
+    return ins.BaseUrl(), nil
+}
+
 // start eureka client
 // 1. parse/get service urls
 // 2. register client to eureka server and send heartbeat
-func (t *Client) Run() {
-    err := t.refreshServiceUrls()
+func (t *Client) Run() error {
+    return t.RunContext(context.Background())
+}
+
+// start eureka client with context,
+// client shuts down (and de-registers) when ctx is done, refer to Shutdown().
+// invalid config is returned as *ConfigError before anything starts
+func (t *Client) RunContext(ctx context.Context) error {
+    err := t.validate()
     if err != nil {
-        log.Errorf("Failed to refresh service urls, err=%s", err.Error())
-        return
+        return err
     }
 
-    // handle exit signal to de-register instance
-    go t.handleSignal()
+    t.mu.Lock()
+    t.ctx, t.cancel = context.WithCancel(ctx)
+    t.mu.Unlock()
+
+    // registering is tracked as well, Shutdown() waits for it
+    t.wg.Add(1)
+    defer t.wg.Done()
+
+    err = t.refreshServiceUrls()
+    if err != nil {
+        return err
+    }
+
+    // (only if HandleExitSignal is true) handle exit signal to de-register instance
+    if t.config.HandleExitSignal {
+        go t.handleSignal()
+    }
+
+    // shutdown while parent ctx is done
+    go func() {
+        <-t.ctx.Done()
+        if ctx.Err() != nil {
+            t.Shutdown(context.Background())
+        }
+    }()
 
     // (if FetchRegistry is true), fetch registry apps periodically
     // and update to t.registryApps
-    go t.refreshRegistry()
+    t.goLoop(t.refreshRegistry)
 
     t.registerWithEureka()
+    return nil
+}
+
+// validate config, and instance to register (while RegisterWithEureka is true)
+func (t *Client) validate() error {
+    if t.config == nil {
+        return &ConfigError{Config: "EurekaClientConfig", Errors: []*FieldError{{Field: "config", Message: "is required, refer to Config()"}}}
+    }
+
+    errs := &ConfigError{Config: "EurekaClientConfig"}
+    errors.As(t.config.validate(t.instance), &errs)
+
+    // health check handler is polled every InstanceInfoReplicationIntervalSeconds
+    if t.healthCheckHandler != nil {
+        if t.config.InstanceInfoReplicationIntervalSeconds <= 0 {
+            errs.add("InstanceInfoReplicationIntervalSeconds", "should be greater than 0 while health check handler is set, got %d",
+                t.config.InstanceInfoReplicationIntervalSeconds)
+        }
+        if t.config.InitialInstanceInfoReplicationIntervalSeconds <= 0 {
+            errs.add("InitialInstanceInfoReplicationIntervalSeconds", "should be greater than 0 while health check handler is set, got %d",
+                t.config.InitialInstanceInfoReplicationIntervalSeconds)
+        }
+    }
+
+    if t.config.RegisterWithEureka && t.instance == nil {
+        if t.instanceErr != nil {
+            errs.add("instance", "failed to build instance from config, %s", t.instanceErr.Error())
+        } else {
+            errs.add("RegisterWithEureka", "instance is required while RegisterWithEureka is true, refer to Register()")
+        }
+    }
+
+    return errs.errOrNil()
+}
+
+// stop heartbeat, registry refresh and dns refresh goroutines,
+// and de-register instance (if ShouldUnregisterOnShutdown is true).
+// ctx limits the time waiting for goroutines to exit and de-registering.
+func (t *Client) Shutdown(ctx context.Context) error {
+    t.mu.RLock()
+    cancel := t.cancel
+    t.mu.RUnlock()
+    if cancel == nil {
+        // client not running
+        return nil
+    }
+
+    var err error
+    t.stopOnce.Do(func() {
+        cancel()
+
+        // wait for goroutines to exit
+        done := make(chan struct{})
+        go func() {
+            t.wg.Wait()
+            close(done)
+        }()
+        select {
+        case <-done:
+        case <-ctx.Done():
+            err = ctx.Err()
+            log.Errorf("Failed to wait for client goroutines to exit, err=%s", err.Error())
+        }
+
+        // de-register anyway, otherwise instance stays in eureka server till evicted
+        unregisterCtx := ctx
+        if ctx.Err() != nil {
+            var cancel context.CancelFunc
+            unregisterCtx, cancel = context.WithTimeout(context.Background(), time.Second*DEFAULT_UNREGISTER_TIMEOUT)
+            defer cancel()
+        }
+        unregisterErr := t.unregister(unregisterCtx)
+        if err == nil {
+            err = unregisterErr
+        }
+    })
+
+    return err
+}
+
+// de-register instance while it was registered and ShouldUnregisterOnShutdown is true
+func (t *Client) unregister(ctx context.Context) error {
+    t.mu.RLock()
+    registered := t.registered
+    t.mu.RUnlock()
+    if !registered || !t.config.ShouldUnregisterOnShutdown {
+        return nil
+    }
+
+    api, err := t.RetryableApi()
+    if err != nil {
+        log.Errorf("Failed to get EurekaServerApi instance, de-register %s failed, err=%s", t.instance.InstanceId, err.Error())
+        return err
+    }
+
+    err = api.DoContext(ctx, func(api *EurekaServerApi) error {
+        return api.DeRegisterInstanceContext(ctx, t.instance.App, t.instance.InstanceId)
+    })
+    if err != nil {
+        log.Errorf("Failed to de-register %s, err=%s", t.instance.InstanceId, err.Error())
+        return err
+    }
+
+    t.mu.Lock()
+    t.registered = false
+    t.mu.Unlock()
+
+    log.Infof("de-register %s success.", t.instance.InstanceId)
+    return nil
+}
+
+// run f in a goroutine tracked by Shutdown()
+func (t *Client) goLoop(f func()) {
+    t.wg.Add(1)
+    go func() {
+        defer t.wg.Done()
+        f()
+    }()
+}
+
+// context of client lifecycle, background while client not running
+func (t *Client) context() context.Context {
+    t.mu.RLock()
+    defer t.mu.RUnlock()
+
+    if t.ctx == nil {
+        return context.Background()
+    }
+    return t.ctx
+}
+
+// sleep for d, return false while client is shutting down
+func (t *Client) sleep(d time.Duration) bool {
+    timer := time.NewTimer(d)
+    defer timer.Stop()
+
+    select {
+    case <-t.ctx.Done():
+        return false
+    case <-timer.C:
+        return true
+    }
 }
 
 func (t *Client) refreshServiceUrls() error {
@@ -112,18 +625,16 @@ func (t *Client) refreshServiceUrls() error {
 
     // auto update service urls
     // (only) while userDnsForFetchingServiceUrls=true and AutoUpdateDnsServiceUrls=true
-    go func() {
-        if !t.config.UseDnsForFetchingServiceUrls && t.config.AutoUpdateDnsServiceUrls {
-            return
-        }
+    if !t.config.UseDnsForFetchingServiceUrls || !t.config.AutoUpdateDnsServiceUrls {
+        return nil
+    }
 
-        for {
+    t.goLoop(func() {
+        for t.sleep(time.Duration(t.config.AutoUpdateDnsServiceUrlsIntervals) * time.Second) {
             t.getServiceUrlsWithZones()
-
-            time.Sleep(time.Duration(t.config.AutoUpdateDnsServiceUrlsIntervals) * time.Second)
             log.Debugf("AutoUpdateDnsServiceUrls... ok")
         }
-    }()
+    })
 
     return nil
 }
@@ -143,14 +654,61 @@ func (t *Client) getServiceUrlsWithZones() error {
         }
 
         t.mu.Lock()
-        t.mu.Unlock()
         t.serviceUrls = urls
+        if t.retryableApi != nil {
+            t.retryableApi.SetServiceUrls(urls)
+        }
+        t.mu.Unlock()
+        t.getMetrics().SetGauge(METRIC_SERVICE_URLS, nil, float64(len(urls)))
         break
     }
 
+    t.getMetrics().IncCounter(METRIC_SERVICE_URL_REFRESH_TOTAL, map[string]string{"result": metricResult(err)})
     return err
 }
 
+// metrics hook, discards metrics while not set
+func (t *Client) getMetrics() Metrics {
+    if t.metrics == nil {
+        return nopMetrics{}
+    }
+    return t.metrics
+}
+
+// Api with failover across service urls (in zone-aware order),
+// failing service urls are quarantined for EurekaServerQuarantineSeconds
+func (t *Client) RetryableApi() (*RetryableEurekaServerApi, error) {
+    t.mu.RLock()
+    api := t.retryableApi
+    t.mu.RUnlock()
+    if api != nil {
+        return api, nil
+    }
+
+    if len(t.serviceUrls) == 0 {
+        // if serviceUrls not init, try to fetch service urls one time
+        err := t.getServiceUrlsWithZones()
+        if err != nil {
+            return nil, err
+        }
+    }
+
+    opts, err := t.apiOptions()
+    if err != nil {
+        return nil, err
+    }
+
+    t.mu.Lock()
+    defer t.mu.Unlock()
+    if t.retryableApi == nil {
+        t.retryableApi = NewRetryableEurekaServerApi(t.serviceUrls, opts...)
+        t.retryableApi.QuarantineDuration = time.Second * time.Duration(t.config.EurekaServerQuarantineSeconds)
+        t.retryableApi.MaxRetries = t.config.EurekaServerMaxRetries
+    }
+
+    return t.retryableApi, nil
+}
+
 // rand to pick service url
 func (t *Client) pickServiceUrl() (string, bool) {
     if len(t.serviceUrls) == 0 {
@@ -164,56 +722,143 @@ func (t *Client) pickServiceUrl() (string, bool) {
     t.mu.RLock()
     defer t.mu.RUnlock()
 
-    index := 0
-    if len(t.serviceUrls) > 1 {
-        index = rand.Intn(len(t.serviceUrls) - 1)
+    if len(t.serviceUrls) == 0 {
+        return "", false
     }
-    return t.serviceUrls[index], true
+    return t.serviceUrls[rand.Intn(len(t.serviceUrls))], true
 }
 
 // rand to pick service url and new EurekaServerApi instance
 func (t *Client) pickEurekaServerApi() (*EurekaServerApi, error) {
     url, ok := t.pickServiceUrl()
     if !ok {
-        log.Errorf("No service url is available to pick.")
-        return nil, errors.New("No service url is available to pick.")
+        log.Errorf(ErrNoServiceUrl.Error())
+        return nil, ErrNoServiceUrl
     }
 
-    return NewEurekaServerApi(url), nil
+    opts, err := t.apiOptions()
+    if err != nil {
+        return nil, err
+    }
+
+    return NewEurekaServerApi(url, opts...), nil
+}
+
+// options of EurekaServerApi: (shared) http client, custom headers, codec, gzip and metrics
+func (t *Client) apiOptions() ([]ApiOption, error) {
+    t.mu.Lock()
+    defer t.mu.Unlock()
+
+    if t.httpClient == nil {
+        httpClient, err := newHttpClient(t.config)
+        if err != nil {
+            log.Errorf("Failed to build http client, err=%s", err.Error())
+            return nil, err
+        }
+        t.httpClient = httpClient
+    }
+
+    opts := []ApiOption{
+        WithHeaders(t.config.EurekaServerHeaders),
+        WithCodec(GetCodec(t.config.Codec)),
+        WithGZipContent(t.config.GZipContent),
+    }
+    opts = append(opts, WithHttpClient(t.httpClient))
+    if t.metrics != nil {
+        opts = append(opts, WithMetrics(t.metrics))
+    }
+
+    return opts, nil
 }
 
 // register instance (default current status is STARTING)
-// and update instance status to UP
+// and update instance status to UP, then send heartbeat
 func (t *Client) registerWithEureka() {
     if !t.config.RegisterWithEureka {
         return
     }
 
+    if !t.register() {
+        return
+    }
+
+    // send heartbeat
+    t.heartbeat()
+
+    // (only if health check handler is set) poll health check and update status
+    t.healthCheck()
+}
+
+// register instance and update instance status to UP, retry till success,
+// return false while client is shutting down
+func (t *Client) register() bool {
+    // retry with exponential backoff, DEFAULT_SLEEP_INTERVALS * 2^n (up to HeartbeatExecutorExponentialBackOffBound times)
+    retry := newBackoff(time.Second*DEFAULT_SLEEP_INTERVALS, t.config.HeartbeatExecutorExponentialBackOffBound)
+
     // ensure client succeed to register to eureka server
     for {
         if t.instance == nil {
             log.Errorf("Eureka instance can't be nil")
-            return
+            return false
         }
 
-        api, err := t.Api()
+        api, err := t.RetryableApi()
         if err != nil {
-            time.Sleep(time.Second * DEFAULT_SLEEP_INTERVALS)
+            if !t.sleep(retry.next()) {
+                return false
+            }
             continue
         }
 
-        instanceId, err := api.RegisterInstanceWithVo(t.instance)
+        // register a copy, metadata may be updated by SetMetadata() meanwhile
+        t.mu.RLock()
+        vo := *t.instance
+        t.mu.RUnlock()
+
+        err = api.DoContext(t.context(), func(api *EurekaServerApi) error {
+            _, err := api.RegisterInstanceWithVoContext(t.ctx, &vo)
+            return err
+        })
+        t.getMetrics().IncCounter(METRIC_REGISTER_TOTAL, map[string]string{"result": metricResult(err)})
         if err != nil {
             log.Errorf("Client register failed, err=%s", err.Error())
-            time.Sleep(time.Second * DEFAULT_SLEEP_INTERVALS)
+            if !t.sleep(retry.next()) {
+                return false
+            }
             continue
         }
-        t.instance.InstanceId = instanceId
+        t.mu.Lock()
+        t.instance.InstanceId = vo.InstanceId
+        t.instance.HomePageUrl = vo.HomePageUrl
+        t.instance.StatusPageUrl = vo.StatusPageUrl
+        t.instance.HealthCheckUrl = vo.HealthCheckUrl
+        t.registered = true
+        // metadata set by SetMetadata() after the copy was registered, pushed here,
+        // SetMetadata() pushes by itself from now on
+        changed := changedMetadata(vo.Metadata, t.instance.Metadata)
+        t.mu.Unlock()
+
+        if len(changed) > 0 {
+            err = api.DoContext(t.context(), func(api *EurekaServerApi) error {
+                return api.UpdateMetaContext(t.context(), vo.App, vo.InstanceId, changed)
+            })
+            if err != nil {
+                // register again with latest metadata
+                log.Errorf("Failed to update metadata, err=%s", err.Error())
+                if !t.sleep(retry.next()) {
+                    return false
+                }
+                continue
+            }
+        }
 
-        err = api.UpdateInstanceStatus(t.instance.App, t.instance.InstanceId, STATUS_UP)
+        // UP, or status by health check handler
+        err = t.updateStatus(t.healthStatus(STATUS_UP))
         if err != nil {
             log.Errorf("Client UP failed, err=%s", err.Error())
-            time.Sleep(time.Second * DEFAULT_SLEEP_INTERVALS)
+            if !t.sleep(retry.next()) {
+                return false
+            }
             continue
         }
 
@@ -222,31 +867,57 @@ func (t *Client) registerWithEureka() {
         break;
     }
 
-    // send heartbeat
-    t.heartbeat()
+    return true
 }
 
-// eureka client heartbeat
+// eureka client heartbeat, every LeaseInfo.RenewalIntervalInSecs of instance (HeartbeatIntervals while not set)
 func (t *Client) heartbeat() {
-    go func() {
+    retry := newBackoff(time.Second*DEFAULT_SLEEP_INTERVALS, t.config.HeartbeatExecutorExponentialBackOffBound)
+    interval := time.Second * time.Duration(t.config.heartbeatInterval(t.instance))
+
+    t.goLoop(func() {
         for {
-            api, err := t.Api()
+            api, err := t.RetryableApi()
             if err != nil {
-                time.Sleep(time.Second * DEFAULT_SLEEP_INTERVALS)
+                if !t.sleep(retry.next()) {
+                    return
+                }
                 continue
             }
 
-            err = api.SendHeartbeat(t.instance.App, t.instance.InstanceId)
+            err = api.DoContext(t.context(), func(api *EurekaServerApi) error {
+                return api.SendHeartbeatContext(t.ctx, t.instance.App, t.instance.InstanceId)
+            })
+            t.getMetrics().IncCounter(METRIC_HEARTBEAT_TOTAL, map[string]string{"result": metricResult(err)})
+            // instance is not known by eureka server, e.g: evicted or eureka server restarted,
+            // register again
+            if errors.Is(err, ErrNotFound) {
+                log.Infof("Instance %s is not known by eureka server, going to register again", t.instance.InstanceId)
+                t.mu.Lock()
+                t.registered = false
+                t.mu.Unlock()
+
+                if !t.register() {
+                    return
+                }
+                retry.reset()
+                continue
+            }
             if err != nil {
                 log.Errorf("Failed to send heartbeat, err=%s", err.Error())
-                time.Sleep(time.Second * DEFAULT_SLEEP_INTERVALS)
+                if !t.sleep(retry.next()) {
+                    return
+                }
                 continue
             }
 
+            retry.reset()
             log.Debugf("Heartbeat app=%s, instanceId=%s", t.instance.App, t.instance.InstanceId)
-            time.Sleep(time.Duration(t.config.HeartbeatIntervals) * time.Second)
+            if !t.sleep(interval) {
+                return
+            }
         }
-    }()
+    })
 }
 
 func (t *Client) refreshRegistry() {
@@ -254,72 +925,348 @@ func (t *Client) refreshRegistry() {
         return
     }
 
+    // on consecutive failures, interval doubles (up to CacheRefreshExecutorExponentialBackOffBound times)
+    interval := time.Second * time.Duration(t.config.RegistryFetchIntervalSeconds)
+    retry := newBackoff(interval, t.config.CacheRefreshExecutorExponentialBackOffBound)
+
+    var lastBackup time.Time
     for {
-        t.fetchRegistry()
-        time.Sleep(time.Second * time.Duration(t.config.RegistryFetchIntervalSeconds))
+        delay := interval
+        apps, err := t.fetchRegistry()
+        if err != nil {
+            delay = retry.next()
+
+            // registry never fetched, e.g: eureka server unreachable on startup,
+            // registry fetched before is never older than the snapshot, keep it
+            if t.GetRegistryApps() == nil {
+                t.loadBackupRegistry()
+            }
+        } else {
+            retry.reset()
+            lastBackup = t.saveBackupRegistry(apps, lastBackup)
+        }
+
+        // (if FetchRemoteRegionsRegistry is set) registries of remote regions,
+        // previous one is kept while failed
+        for _, region := range t.config.GetRemoteRegions() {
+            t.fetchRemoteRegistry(region)
+        }
+
+        if !t.sleep(delay) {
+            return
+        }
     }
 }
 
+// fetch registry apps, fetch delta registry (/apps/delta) while local registry exists and delta is enabled,
+// otherwise fetch full registry (/apps)
 func (t *Client) fetchRegistry() (map[string]ApplicationVo, error) {
-    api, err := t.Api()
+    t.mu.RLock()
+    registryApps := t.registryApps
+    fromBackup := t.registryFromBackup
+    t.mu.RUnlock()
+
+    fetchType := "delta"
+    fetch := func() (map[string]ApplicationVo, error) {
+        return t.fetchDeltaRegistry(registryApps)
+    }
+    // delta can't apply onto registry loaded from backup registry
+    if t.config.DisableDelta || registryApps == nil || fromBackup {
+        fetchType = "full"
+        fetch = t.fetchFullRegistry
+    }
+    // delta covers all apps, disabled while fetching part of registry
+    if t.config.isSelectiveFetch() {
+        fetchType = "selective"
+        fetch = t.fetchSelectiveRegistry
+    }
+
+    start := time.Now()
+    apps, err := fetch()
+    t.getMetrics().IncCounter(METRIC_FETCH_REGISTRY_TOTAL, map[string]string{"type": fetchType, "result": metricResult(err)})
+    t.getMetrics().ObserveHistogram(METRIC_FETCH_REGISTRY_SECONDS, map[string]string{"type": fetchType}, time.Since(start).Seconds())
+    return apps, err
+}
+
+func (t *Client) fetchFullRegistry() (map[string]ApplicationVo, error) {
+    api, err := t.RetryableApi()
     if err != nil {
         log.Errorf("Failed to QueryAllInstances, err=%s", err.Error())
         return nil, err
     }
 
-    apps, err := api.QueryAllInstances()
+    var apps []ApplicationVo
+    err = api.DoContext(t.context(), func(api *EurekaServerApi) error {
+        apps, err = api.QueryAllInstancesContext(t.ctx)
+        return err
+    })
     if err != nil {
         log.Errorf("Failed to QueryAllInstances, err=%s", err.Error())
         return nil, err
     }
 
+    // all instances are kept in registry (which delta applies onto),
+    // FilterOnlyUpInstances effects while looking up instances
+
+    registryApps := make(map[string]ApplicationVo)
+    for _, app := range apps {
+        registryApps[app.Name] = app
+    }
+
+    t.updateRegistryApps(registryApps)
+    return registryApps, nil
+}
+
+// fetch instances of RegistryRefreshSingleVipAddress (/vips/{vipAddress}),
+// or of each app in RegistryFetchAppIds (/apps/{appId})
+func (t *Client) fetchSelectiveRegistry() (map[string]ApplicationVo, error) {
+    api, err := t.RetryableApi()
+    if err != nil {
+        log.Errorf("Failed to fetch selective registry, err=%s", err.Error())
+        return nil, err
+    }
+
+    registryApps := make(map[string]ApplicationVo)
+    if vipAddress := t.config.RegistryRefreshSingleVipAddress; vipAddress != "" {
+        var apps []ApplicationVo
+        err = api.DoContext(t.context(), func(api *EurekaServerApi) error {
+            apps, err = api.QueryAllVipInstancesContext(t.ctx, vipAddress)
+            return err
+        })
+        if err != nil {
+            log.Errorf("Failed to QueryAllVipInstances, vip=%s, err=%s", vipAddress, err.Error())
+            return nil, err
+        }
+
+        for _, app := range apps {
+            registryApps[app.Name] = app
+        }
+    }
+
+    for _, appId := range t.config.GetRegistryFetchAppIds() {
+        var instances []InstanceVo
+        err = api.DoContext(t.context(), func(api *EurekaServerApi) error {
+            instances, err = api.QueryAllInstanceByAppIdContext(t.ctx, appId)
+            return err
+        })
+        // no instance of app registered
+        if errors.Is(err, ErrNotFound) {
+            continue
+        }
+        if err != nil {
+            log.Errorf("Failed to QueryAllInstanceByAppId, app=%s, err=%s", appId, err.Error())
+            return nil, err
+        }
+
+        if len(instances) > 0 {
+            registryApps[appId] = ApplicationVo{Name: appId, Instances: instances}
+        }
+    }
+
+    t.updateRegistryApps(registryApps)
+    return registryApps, nil
+}
+
+// fetch delta registry and apply it onto a copy of local registry,
+// then reconcile with apps hash code, fall back to full registry while mismatch
+func (t *Client) fetchDeltaRegistry(registryApps map[string]ApplicationVo) (map[string]ApplicationVo, error) {
+    api, err := t.RetryableApi()
+    if err != nil {
+        log.Errorf("Failed to QueryDeltaInstances, err=%s", err.Error())
+        return nil, err
+    }
+
+    var delta *ApplicationsVo
+    err = api.DoContext(t.context(), func(api *EurekaServerApi) error {
+        delta, err = api.QueryDeltaInstancesContext(t.ctx)
+        return err
+    })
+    if err != nil {
+        log.Errorf("Failed to QueryDeltaInstances, err=%s", err.Error())
+        return nil, err
+    }
+
+    apps := copyRegistryApps(registryApps)
+    applyDelta(apps, delta)
+
+    hashCode := getReconcileHashCode(apps)
+    if hashCode != delta.AppsHashCode {
+        log.Infof("Registry hash code mismatch, local=%s, remote=%s, going to fetch full registry", hashCode, delta.AppsHashCode)
+        fullApps, err := t.fetchFullRegistry()
+        if err != nil {
+            return nil, err
+        }
+
+        if t.config.LogDeltaDiff {
+            logRegistryDiff(apps, fullApps)
+        }
+        return fullApps, nil
+    }
+
+    t.updateRegistryApps(apps)
+    return apps, nil
+}
+
+// fetch full registry of remote region
+func (t *Client) fetchRemoteRegistry(region string) error {
+    start := time.Now()
+    var apps []ApplicationVo
+    api, err := t.remoteRegionApi(region)
+    if err == nil {
+        err = api.DoContext(t.context(), func(api *EurekaServerApi) error {
+            apps, err = api.QueryAllInstancesContext(t.ctx)
+            return err
+        })
+    }
+    t.getMetrics().IncCounter(METRIC_FETCH_REGISTRY_TOTAL, map[string]string{"type": "remote", "result": metricResult(err)})
+    t.getMetrics().ObserveHistogram(METRIC_FETCH_REGISTRY_SECONDS, map[string]string{"type": "remote"}, time.Since(start).Seconds())
+    if err != nil {
+        log.Errorf("Failed to fetch registry of remote region=%s, err=%s", region, err.Error())
+        return err
+    }
+
+    registryApps := make(map[string]ApplicationVo)
+    for _, app := range apps {
+        registryApps[app.Name] = app
+    }
+
+    t.mu.Lock()
+    if t.remoteRegistryApps == nil {
+        t.remoteRegistryApps = map[string]map[string]ApplicationVo{}
+    }
+    t.remoteRegistryApps[region] = registryApps
+    t.mu.Unlock()
+    return nil
+}
+
+// Api with failover across service urls of remote region
+func (t *Client) remoteRegionApi(region string) (*RetryableEurekaServerApi, error) {
+    t.mu.RLock()
+    api := t.remoteApis[region]
+    t.mu.RUnlock()
+    if api != nil {
+        return api, nil
+    }
+
+    urls, err := new(EndpointUtils).GetRegionServiceUrls(t.config, region)
+    if err != nil {
+        return nil, err
+    }
+    if len(urls) == 0 {
+        return nil, ErrNoServiceUrl
+    }
+
+    opts, err := t.apiOptions()
+    if err != nil {
+        return nil, err
+    }
+
+    api = NewRetryableEurekaServerApi(urls, opts...)
+    api.QuarantineDuration = time.Second * time.Duration(t.config.EurekaServerQuarantineSeconds)
+    api.MaxRetries = t.config.EurekaServerMaxRetries
+
     t.mu.Lock()
     defer t.mu.Unlock()
+    if t.remoteApis == nil {
+        t.remoteApis = map[string]*RetryableEurekaServerApi{}
+    }
+    if t.remoteApis[region] == nil {
+        t.remoteApis[region] = api
+    }
+
+    return t.remoteApis[region], nil
+}
 
-    // @TODO  FilterOnlyUpInstances  true,
+// replace local registry with new snapshot fetched, and publish registry change events
+func (t *Client) updateRegistryApps(apps map[string]ApplicationVo) {
+    t.setRegistryApps(apps, time.Now(), false)
+}
+
+func (t *Client) setRegistryApps(apps map[string]ApplicationVo, registryTime time.Time, fromBackup bool) {
+    t.mu.Lock()
+    prev := t.registryApps
+    t.registryApps = apps
+    t.registryTime = registryTime
+    t.registryFromBackup = fromBackup
+    t.mu.Unlock()
 
-    t.registryApps = make(map[string]ApplicationVo)
+    instances := 0
     for _, app := range apps {
-        t.registryApps[app.Name] = app
+        instances += len(app.Instances)
     }
+    t.getMetrics().SetGauge(METRIC_REGISTRY_APPS, nil, float64(len(apps)))
+    t.getMetrics().SetGauge(METRIC_REGISTRY_INSTANCES, nil, float64(instances))
 
-    return t.registryApps, nil
+    t.publishRegistryEvents(diffRegistry(prev, apps))
+}
+
+// backup registry to snapshot into and bootstrap from, nil while not set
+func (t *Client) getBackupRegistry() BackupRegistry {
+    if t.backupRegistry != nil {
+        return t.backupRegistry
+    }
+    if t.config.BackupRegistryFile != "" {
+        return NewFileBackupRegistry(t.config.BackupRegistryFile)
+    }
+
+    return nil
+}
+
+// bootstrap local registry from backup registry
+func (t *Client) loadBackupRegistry() {
+    backupRegistry := t.getBackupRegistry()
+    if backupRegistry == nil {
+        return
+    }
+
+    apps, snapshotTime, err := backupRegistry.Load()
+    if err != nil {
+        log.Errorf("Failed to load backup registry, err=%s", err.Error())
+        return
+    }
+
+    log.Infof("Registry is loaded from backup registry, apps=%d, snapshot time=%s", len(apps), snapshotTime.Format(time.RFC3339))
+    t.setRegistryApps(apps, snapshotTime, true)
+}
+
+// snapshot registry into backup registry, at most once every BackupRegistryIntervalSeconds,
+// return time of last snapshot
+func (t *Client) saveBackupRegistry(apps map[string]ApplicationVo, lastBackup time.Time) time.Time {
+    backupRegistry := t.getBackupRegistry()
+    if backupRegistry == nil || time.Since(lastBackup) < time.Duration(t.config.BackupRegistryIntervalSeconds)*time.Second {
+        return lastBackup
+    }
+
+    err := backupRegistry.Save(apps)
+    if err != nil {
+        log.Errorf("Failed to save backup registry, err=%s", err.Error())
+        return lastBackup
+    }
+
+    return time.Now()
 }
 
 // for graceful kill. Here handle SIGTERM signal to do sth
 // e.g: kill -TERM $pid
 //      or "ctrl + c" to exit
+// (only when HandleExitSignal is true), shutdown client then exit process
 func (t *Client) handleSignal() {
     if t.signalChan == nil {
-        t.signalChan = make(chan os.Signal)
+        t.signalChan = make(chan os.Signal, 1)
     }
 
-    signal.Notify(t.signalChan, syscall.SIGTERM, syscall.SIGINT, syscall.SIGKILL)
+    signal.Notify(t.signalChan, syscall.SIGTERM, syscall.SIGINT)
+    defer signal.Stop(t.signalChan)
 
-    for {
-        switch <-t.signalChan {
-        case syscall.SIGINT:
-            fallthrough
-        case syscall.SIGKILL:
-            fallthrough
-        case syscall.SIGTERM:
-            log.Infof("Receive exit signal, client instance going to de-register, instanceId=%s.", t.instance.InstanceId)
-
-            // de-register instance
-            api, err := t.Api()
-            if err != nil {
-                log.Errorf("Failed to get EurekaServerApi instance, de-register %s failed, err=%s", t.instance.InstanceId, err.Error())
-                return
-            }
-
-            err = api.DeRegisterInstance(t.instance.App, t.instance.InstanceId)
-            if err != nil {
-                log.Errorf("Failed to de-register %s, err=%s", t.instance.InstanceId, err.Error())
-                return
-            }
+    select {
+    case <-t.ctx.Done():
+        return
+    case sig := <-t.signalChan:
+        log.Infof("Receive exit signal %s, client going to shutdown.", sig)
 
-            log.Infof("de-register %s success.", t.instance.InstanceId)
-            os.Exit(0)
-        }
+        ctx, cancel := context.WithTimeout(context.Background(), time.Second*DEFAULT_SHUTDOWN_TIMEOUT)
+        defer cancel()
+        t.Shutdown(ctx)
+        os.Exit(0)
     }
 }
@@ -0,0 +1,454 @@
+package eureka_test
+
+import (
+    "context"
+    "errors"
+    "path/filepath"
+    "strings"
+    "sync/atomic"
+    "testing"
+    "time"
+
+    "github.com/HikoQiu/go-eureka-client/eureka"
+    "github.com/HikoQiu/go-eureka-client/eureka/eurekatest"
+)
+
+func getTestClientConfig(server *eurekatest.Server) *eureka.EurekaClientConfig {
+    config := eureka.GetDefaultEurekaClientConfig()
+    config.ServiceUrl = map[string]string{
+        eureka.DEFAULT_ZONE: server.ServiceUrl(),
+    }
+    config.RegistryFetchIntervalSeconds = 1
+    config.HeartbeatIntervals = 1
+
+    return config
+}
+
+// instance sending heartbeat every second
+func getTestInstanceConfig() *eureka.EurekaInstanceConfig {
+    config := eureka.GetDefaultEurekaInstanceConfig()
+    config.Appname = test_app_name
+    config.NonSecurePort = test_instance_port
+    config.LeaseRenewalIntervalInSeconds = 1
+
+    return config
+}
+
+// wait until cond is true or timeout
+func waitFor(t *testing.T, timeout time.Duration, cond func() bool) {
+    deadline := time.Now().Add(timeout)
+    for !cond() {
+        if time.Now().After(deadline) {
+            t.Fatal("Timeout waiting for condition")
+        }
+        time.Sleep(50 * time.Millisecond)
+    }
+}
+
+func Test_ClientRunAndShutdown(t *testing.T) {
+    server := eurekatest.NewServer()
+    defer server.Close()
+
+    client := new(eureka.Client).Config(getTestClientConfig(server)).RegisterConfig(getTestInstanceConfig())
+    err := client.Run()
+    if err != nil {
+        t.Fatal(err.Error())
+    }
+
+    instances := server.Instances(test_app_name)
+    if len(instances) != 1 || instances[0].Status != eureka.STATUS_UP {
+        t.Fatalf("Expected 1 UP instance registered, got %v", instances)
+    }
+
+    // registry fetched into local cache
+    waitFor(t, 5*time.Second, func() bool {
+        return len(client.GetInstancesByAppId(test_app_name)) == 1
+    })
+    if len(client.GetInstancesByVipAddress(test_app_name, false)) != 1 || len(client.GetInstancesByVipAddress(test_app_name, true)) != 1 {
+        t.Fatal("Expected 1 instance found by vip address and secure vip address")
+    }
+
+    ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+    defer cancel()
+    err = client.Shutdown(ctx)
+    if err != nil {
+        t.Fatal(err.Error())
+    }
+
+    if instances := server.Instances(test_app_name); len(instances) != 0 {
+        t.Fatalf("Instance should be de-registered on shutdown, got %v", instances)
+    }
+}
+
+func Test_ClientShutdownContextDone(t *testing.T) {
+    server := eurekatest.NewServer()
+    defer server.Close()
+
+    client := new(eureka.Client).Config(getTestClientConfig(server)).RegisterConfig(getTestInstanceConfig())
+    err := client.Run()
+    if err != nil {
+        t.Fatal(err.Error())
+    }
+
+    // no time to wait for goroutines, instance is de-registered anyway
+    ctx, cancel := context.WithCancel(context.Background())
+    cancel()
+    client.Shutdown(ctx)
+
+    if instances := server.Instances(test_app_name); len(instances) != 0 {
+        t.Fatalf("Instance should be de-registered on shutdown, got %v", instances)
+    }
+}
+
+func Test_ClientRunInvalidConfig(t *testing.T) {
+    config := eureka.GetDefaultEurekaClientConfig()
+    config.ServiceUrl = map[string]string{}
+    config.HeartbeatIntervals = 120
+
+    // lease of instance: 90 seconds, renewal interval not set, HeartbeatIntervals used
+    vo := eureka.DefaultInstanceVo()
+    vo.App = test_app_name
+    vo.LeaseInfo.RenewalIntervalInSecs = 0
+    err := new(eureka.Client).Config(config).RegisterVo(vo).Run()
+    configErr := &eureka.ConfigError{}
+    if !errors.As(err, &configErr) || len(configErr.Errors) != 2 {
+        t.Fatalf("Expected ServiceUrl and HeartbeatIntervals reported, got %v", err)
+    }
+
+    // renewal interval of instance overrides HeartbeatIntervals, e.g: lease-renewal-interval-in-seconds: 5,
+    // only ServiceUrl reported
+    instanceConfig := getTestInstanceConfig()
+    instanceConfig.LeaseRenewalIntervalInSeconds = 5
+    instanceConfig.LeaseExpirationDurationInSeconds = 15
+    err = new(eureka.Client).Config(config).RegisterConfig(instanceConfig).Run()
+    if !errors.As(err, &configErr) || len(configErr.Errors) != 1 || strings.Contains(err.Error(), "HeartbeatIntervals") {
+        t.Fatalf("HeartbeatIntervals should not be checked against lease of instance, got %v", err)
+    }
+
+    // no instance to register
+    err = new(eureka.Client).Config(eureka.GetDefaultEurekaClientConfig()).Run()
+    if err == nil || !strings.Contains(err.Error(), "RegisterWithEureka") {
+        t.Fatalf("Expected RegisterWithEureka reported, got %v", err)
+    }
+
+    // health check handler polled without interval
+    config = eureka.GetDefaultEurekaClientConfig()
+    config.InstanceInfoReplicationIntervalSeconds = 0
+    err = new(eureka.Client).Config(config).RegisterConfig(getTestInstanceConfig()).
+        HealthCheckHandler(eureka.HealthCheckHandlerFunc(func(currentStatus string) string { return currentStatus })).Run()
+    if err == nil || !strings.Contains(err.Error(), "InstanceInfoReplicationIntervalSeconds") {
+        t.Fatalf("Expected InstanceInfoReplicationIntervalSeconds reported, got %v", err)
+    }
+
+    // instance failed to build from config
+    err = new(eureka.Client).Config(eureka.GetDefaultEurekaClientConfig()).Register(test_app_name, 0).Run()
+    if !errors.As(err, &configErr) || !strings.Contains(err.Error(), "NonSecurePort") {
+        t.Fatalf("Expected NonSecurePort of instance config reported, got %v", err)
+    }
+}
+
+func Test_ClientRunContextCancel(t *testing.T) {
+    server := eurekatest.NewServer()
+    defer server.Close()
+
+    ctx, cancel := context.WithCancel(context.Background())
+    client := new(eureka.Client).Config(getTestClientConfig(server)).RegisterConfig(getTestInstanceConfig())
+    err := client.RunContext(ctx)
+    if err != nil {
+        t.Fatal(err.Error())
+    }
+    cancel()
+
+    waitFor(t, 5*time.Second, func() bool {
+        return len(server.Instances(test_app_name)) == 0
+    })
+}
+
+func Test_ClientHealthCheck(t *testing.T) {
+    server := eurekatest.NewServer()
+    defer server.Close()
+
+    config := getTestClientConfig(server)
+    config.InitialInstanceInfoReplicationIntervalSeconds = 1
+    config.InstanceInfoReplicationIntervalSeconds = 1
+
+    var healthy int32 = 1
+    client := new(eureka.Client).Config(config).RegisterConfig(getTestInstanceConfig())
+    client.HealthCheckHandler(eureka.HealthCheckHandlerFunc(func(currentStatus string) string {
+        if atomic.LoadInt32(&healthy) == 1 {
+            return eureka.STATUS_UP
+        }
+        return eureka.STATUS_DOWN
+    }))
+    err := client.Run()
+    if err != nil {
+        t.Fatal(err.Error())
+    }
+    defer client.Shutdown(context.Background())
+
+    status := func() string {
+        instances := server.Instances(test_app_name)
+        if len(instances) != 1 {
+            return ""
+        }
+        return instances[0].Status
+    }
+    if status() != eureka.STATUS_UP {
+        t.Fatalf("Expected UP instance, got %s", status())
+    }
+
+    atomic.StoreInt32(&healthy, 0)
+    waitFor(t, 5*time.Second, func() bool {
+        return status() == eureka.STATUS_DOWN
+    })
+
+    atomic.StoreInt32(&healthy, 1)
+    waitFor(t, 5*time.Second, func() bool {
+        return status() == eureka.STATUS_UP
+    })
+}
+
+func Test_ClientReRegisterOnHeartbeatNotFound(t *testing.T) {
+    server := eurekatest.NewServer()
+    defer server.Close()
+
+    // heartbeat every LeaseRenewalIntervalInSeconds (1 second) of instance
+    config := getTestClientConfig(server)
+    config.HeartbeatIntervals = 60
+    client := new(eureka.Client).Config(config).RegisterConfig(getTestInstanceConfig())
+    err := client.Run()
+    if err != nil {
+        t.Fatal(err.Error())
+    }
+    defer client.Shutdown(context.Background())
+
+    instanceId := client.GetInstance().InstanceId
+    server.Evict(test_app_name, instanceId)
+    if len(server.Instances(test_app_name)) != 0 {
+        t.Fatal("Instance should be evicted")
+    }
+
+    // heartbeat gets 404 and client registers again
+    waitFor(t, 5*time.Second, func() bool {
+        instances := server.Instances(test_app_name)
+        return len(instances) == 1 && instances[0].InstanceId == instanceId && instances[0].Status == eureka.STATUS_UP
+    })
+}
+
+func Test_ClientMetadata(t *testing.T) {
+    server := eurekatest.NewServer()
+    defer server.Close()
+
+    client := new(eureka.Client).Config(getTestClientConfig(server)).
+        Metadata(map[string]string{"version": "1.0"}).
+        RegisterConfig(getTestInstanceConfig())
+    err := client.Run()
+    if err != nil {
+        t.Fatal(err.Error())
+    }
+    defer client.Shutdown(context.Background())
+
+    if value := server.Instances(test_app_name)[0].Metadata["version"]; value != "1.0" {
+        t.Fatalf("Expected metadata version=1.0 registered, got %s", value)
+    }
+
+    err = client.SetMetadata(map[string]string{"canary": "true", "git-sha": "a1b2&c3"})
+    if err != nil {
+        t.Fatal(err.Error())
+    }
+
+    metadata := server.Instances(test_app_name)[0].Metadata
+    if metadata["version"] != "1.0" || metadata["canary"] != "true" || metadata["git-sha"] != "a1b2&c3" {
+        t.Fatalf("Unexpected metadata in eureka server: %v", metadata)
+    }
+    if client.GetInstance().Metadata["git-sha"] != "a1b2&c3" {
+        t.Fatalf("Unexpected local metadata: %v", client.GetInstance().Metadata)
+    }
+
+    // local metadata is unchanged while eureka server is unavailable
+    server.Close()
+    err = client.SetMetadata(map[string]string{"canary": "false"})
+    if err == nil {
+        t.Fatal("SetMetadata should fail while eureka server is unavailable")
+    }
+    if client.GetInstance().Metadata["canary"] != "true" {
+        t.Fatalf("Unexpected local metadata: %v", client.GetInstance().Metadata)
+    }
+}
+
+func Test_ClientMetrics(t *testing.T) {
+    server := eurekatest.NewServer()
+    defer server.Close()
+
+    metrics := eureka.NewPrometheusMetrics()
+    client := new(eureka.Client).Config(getTestClientConfig(server)).
+        Metrics(metrics).
+        RegisterConfig(getTestInstanceConfig())
+    err := client.Run()
+    if err != nil {
+        t.Fatal(err.Error())
+    }
+    defer client.Shutdown(context.Background())
+
+    expected := []string{
+        `eureka_client_register_total{result="success"} 1`,
+        `eureka_client_heartbeat_total{result="success"}`,
+        `eureka_client_fetch_registry_total{result="success",type="full"} 1`,
+        `eureka_client_registry_instances 1`,
+        `eureka_client_service_urls 1`,
+        `eureka_client_api_requests_total{method="POST",operation="register",status="204"} 1`,
+        `eureka_client_api_request_seconds_count{operation="heartbeat"}`,
+    }
+    waitFor(t, 5*time.Second, func() bool {
+        body := metrics.String()
+        for _, line := range expected {
+            if !strings.Contains(body, line) {
+                return false
+            }
+        }
+        return true
+    })
+}
+
+func Test_ClientBackupRegistry(t *testing.T) {
+    server := eurekatest.NewServer()
+    backupFile := filepath.Join(t.TempDir(), "registry.json")
+
+    config := getTestClientConfig(server)
+    config.BackupRegistryFile = backupFile
+    config.BackupRegistryIntervalSeconds = 1
+    client := new(eureka.Client).Config(config).RegisterConfig(getTestInstanceConfig())
+    err := client.Run()
+    if err != nil {
+        t.Fatal(err.Error())
+    }
+
+    // snapshot with the instance UP
+    waitFor(t, 5*time.Second, func() bool {
+        apps, _, err := eureka.NewFileBackupRegistry(backupFile).Load()
+        instances := apps[strings.ToUpper(test_app_name)].Instances
+        return err == nil && len(instances) == 1 && instances[0].Status == eureka.STATUS_UP
+    })
+    client.Shutdown(context.Background())
+    server.Close()
+
+    // eureka server unreachable, bootstrap from backup registry
+    config = getTestClientConfig(server)
+    config.BackupRegistryFile = backupFile
+    config.RegisterWithEureka = false
+    client = new(eureka.Client).Config(config)
+    err = client.Run()
+    if err != nil {
+        t.Fatal(err.Error())
+    }
+    defer client.Shutdown(context.Background())
+
+    waitFor(t, 5*time.Second, func() bool {
+        return len(client.GetInstancesByAppId(test_app_name)) == 1
+    })
+    age, ok := client.GetRegistryAge()
+    if !ok || !client.IsRegistryFromBackup() || age <= 0 {
+        t.Fatalf("Expected registry from backup registry with age, got age=%s, ok=%t", age, ok)
+    }
+}
+
+func Test_ClientFetchRemoteRegionsRegistry(t *testing.T) {
+    server := eurekatest.NewServer()
+    defer server.Close()
+    remoteServer := eurekatest.NewServer()
+    defer remoteServer.Close()
+
+    // instance in remote region
+    api := eureka.NewEurekaServerApi(remoteServer.ServiceUrl())
+    instanceId, err := api.RegisterInstance("REMOTE-APP", 8080)
+    if err != nil {
+        t.Fatal(err.Error())
+    }
+    err = api.UpdateInstanceStatus("REMOTE-APP", instanceId, eureka.STATUS_UP)
+    if err != nil {
+        t.Fatal(err.Error())
+    }
+
+    config := getTestClientConfig(server)
+    config.Region = "region-a"
+    config.AvailabilityZones = map[string]string{
+        "region-a": "zone-a",
+        "region-b": "zone-b",
+    }
+    config.ServiceUrl = map[string]string{
+        "zone-a": server.ServiceUrl(),
+        "zone-b": remoteServer.ServiceUrl(),
+    }
+    config.FetchRemoteRegionsRegistry = "region-b"
+    client := new(eureka.Client).Config(config).RegisterConfig(getTestInstanceConfig())
+    err = client.Run()
+    if err != nil {
+        t.Fatal(err.Error())
+    }
+    defer client.Shutdown(context.Background())
+
+    waitFor(t, 5*time.Second, func() bool {
+        return len(client.GetAllInstancesByAppId("remote-app", true)) == 1 && len(client.GetRegionRegistryApps("region-a")) == 1
+    })
+    if len(client.GetInstancesByAppId("remote-app")) != 0 || len(client.GetAllInstancesByAppId("remote-app", false)) != 0 {
+        t.Fatal("Remote region instances should be excluded from local lookups")
+    }
+    if len(client.GetInstancesByAppIdAndRegion("remote-app", "REGION-B")) != 1 || len(client.GetInstancesByAppIdAndRegion(test_app_name, "region-b")) != 0 {
+        t.Fatal("Expected instances tagged by region")
+    }
+
+    // remote region without service url, copy of config in use by running client
+    invalid := *config
+    invalid.FetchRemoteRegionsRegistry = "region-b,region-c"
+    err = invalid.Validate()
+    if err == nil || !strings.Contains(err.Error(), "region-c") {
+        t.Fatalf("Expected region-c reported, got %v", err)
+    }
+}
+
+func Test_ClientSelectiveFetch(t *testing.T) {
+    server := eurekatest.NewServer()
+    defer server.Close()
+
+    // another app, not in the whitelist
+    api := eureka.NewEurekaServerApi(server.ServiceUrl())
+    _, err := api.RegisterInstance("OTHER-APP", 8080)
+    if err != nil {
+        t.Fatal(err.Error())
+    }
+
+    config := getTestClientConfig(server)
+    config.RegistryFetchAppIds = test_app_name + ",MISSING-APP"
+    config.FilterOnlyUpInstances = false
+    client := new(eureka.Client).Config(config).RegisterConfig(getTestInstanceConfig())
+    err = client.Run()
+    if err != nil {
+        t.Fatal(err.Error())
+    }
+    defer client.Shutdown(context.Background())
+
+    waitFor(t, 5*time.Second, func() bool {
+        return len(client.GetInstancesByAppId(test_app_name)) == 1
+    })
+    if apps := client.GetRegistryApps(); len(apps) != 1 {
+        t.Fatalf("Expected only whitelisted app fetched, got %v", apps)
+    }
+
+    // single vip
+    config = getTestClientConfig(server)
+    config.RegistryRefreshSingleVipAddress = strings.ToLower(test_app_name)
+    config.RegisterWithEureka = false
+    vipClient := new(eureka.Client).Config(config)
+    err = vipClient.Run()
+    if err != nil {
+        t.Fatal(err.Error())
+    }
+    defer vipClient.Shutdown(context.Background())
+
+    waitFor(t, 5*time.Second, func() bool {
+        return len(vipClient.GetInstancesByAppId(test_app_name)) == 1
+    })
+    if apps := vipClient.GetRegistryApps(); len(apps) != 1 {
+        t.Fatalf("Expected only app of vip fetched, got %v", apps)
+    }
+}
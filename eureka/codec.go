@@ -0,0 +1,226 @@
+package eureka
+
+import (
+    "encoding/json"
+    "encoding/xml"
+    "sort"
+    "strings"
+)
+
+const (
+    CODEC_JSON = "json"
+    CODEC_XML  = "xml"
+
+    CONTENT_TYPE_JSON = "application/json"
+    CONTENT_TYPE_XML  = "application/xml"
+)
+
+// encode/decode eureka rest payloads, JSON or XML
+type Codec interface {
+    // content type of encoded payload, also used as Accept header
+    ContentType() string
+
+    EncodeInstance(vo *InstanceVo) ([]byte, error)
+    EncodeApplication(vo *ApplicationVo) ([]byte, error)
+    EncodeApplications(vo *ApplicationsVo) ([]byte, error)
+
+    DecodeInstance(body []byte) (*InstanceVo, error)
+    DecodeApplication(body []byte) (*ApplicationVo, error)
+    DecodeApplications(body []byte) (*ApplicationsVo, error)
+}
+
+// get codec by name: json | xml, default: json
+func GetCodec(name string) Codec {
+    if strings.ToLower(name) == CODEC_XML {
+        return new(XmlCodec)
+    }
+
+    return new(JsonCodec)
+}
+
+// get codec by content type (or Accept header), e.g: application/xml;charset=UTF-8,
+// default: json
+func GetCodecByContentType(contentType string) Codec {
+    if strings.Contains(strings.ToLower(contentType), CODEC_XML) {
+        return new(XmlCodec)
+    }
+
+    return new(JsonCodec)
+}
+
+// JSON codec, payloads are wrapped by root name, e.g: {"instance": {...}}
+type JsonCodec struct {
+}
+
+func (t *JsonCodec) ContentType() string {
+    return CONTENT_TYPE_JSON
+}
+
+func (t *JsonCodec) EncodeInstance(vo *InstanceVo) ([]byte, error) {
+    return json.Marshal(map[string]interface{}{"instance": vo})
+}
+
+func (t *JsonCodec) EncodeApplication(vo *ApplicationVo) ([]byte, error) {
+    return json.Marshal(map[string]interface{}{"application": vo})
+}
+
+func (t *JsonCodec) EncodeApplications(vo *ApplicationsVo) ([]byte, error) {
+    return json.Marshal(map[string]interface{}{"applications": vo})
+}
+
+func (t *JsonCodec) DecodeInstance(body []byte) (*InstanceVo, error) {
+    res := make(map[string]*InstanceVo)
+    err := json.Unmarshal(body, &res)
+    if err != nil {
+        return nil, err
+    }
+
+    return res["instance"], nil
+}
+
+func (t *JsonCodec) DecodeApplication(body []byte) (*ApplicationVo, error) {
+    res := make(map[string]*ApplicationVo)
+    err := json.Unmarshal(body, &res)
+    if err != nil {
+        return nil, err
+    }
+
+    if res["application"] == nil {
+        return &ApplicationVo{}, nil
+    }
+    return res["application"], nil
+}
+
+func (t *JsonCodec) DecodeApplications(body []byte) (*ApplicationsVo, error) {
+    res := make(map[string]*ApplicationsVo)
+    err := json.Unmarshal(body, &res)
+    if err != nil {
+        return nil, err
+    }
+
+    if res["applications"] == nil {
+        return &ApplicationsVo{}, nil
+    }
+    return res["applications"], nil
+}
+
+// XML codec, e.g: <instance>...</instance>
+type XmlCodec struct {
+}
+
+func (t *XmlCodec) ContentType() string {
+    return CONTENT_TYPE_XML
+}
+
+func (t *XmlCodec) EncodeInstance(vo *InstanceVo) ([]byte, error) {
+    return xml.Marshal(struct {
+        XMLName xml.Name `xml:"instance"`
+        *InstanceVo
+    }{InstanceVo: vo})
+}
+
+func (t *XmlCodec) EncodeApplication(vo *ApplicationVo) ([]byte, error) {
+    return xml.Marshal(struct {
+        XMLName xml.Name `xml:"application"`
+        *ApplicationVo
+    }{ApplicationVo: vo})
+}
+
+func (t *XmlCodec) EncodeApplications(vo *ApplicationsVo) ([]byte, error) {
+    return xml.Marshal(struct {
+        XMLName xml.Name `xml:"applications"`
+        *ApplicationsVo
+    }{ApplicationsVo: vo})
+}
+
+func (t *XmlCodec) DecodeInstance(body []byte) (*InstanceVo, error) {
+    vo := new(InstanceVo)
+    err := xml.Unmarshal(body, vo)
+    if err != nil {
+        return nil, err
+    }
+
+    return vo, nil
+}
+
+func (t *XmlCodec) DecodeApplication(body []byte) (*ApplicationVo, error) {
+    vo := new(ApplicationVo)
+    err := xml.Unmarshal(body, vo)
+    if err != nil {
+        return nil, err
+    }
+
+    return vo, nil
+}
+
+func (t *XmlCodec) DecodeApplications(body []byte) (*ApplicationsVo, error) {
+    vo := new(ApplicationsVo)
+    err := xml.Unmarshal(body, vo)
+    if err != nil {
+        return nil, err
+    }
+
+    return vo, nil
+}
+
+// instance metadata, in XML each entry is an element, e.g:
+// <metadata><version>1.0</version></metadata>
+type InstanceMetadata map[string]string
+
+func (t InstanceMetadata) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+    err := e.EncodeToken(start)
+    if err != nil {
+        return err
+    }
+
+    keys := make([]string, 0, len(t))
+    for k := range t {
+        keys = append(keys, k)
+    }
+    sort.Strings(keys)
+
+    for _, k := range keys {
+        err = e.EncodeElement(t[k], xml.StartElement{Name: xml.Name{Local: k}})
+        if err != nil {
+            return err
+        }
+    }
+
+    return e.EncodeToken(start.End())
+}
+
+// "@class" (type hint of Java's map, e.g: java.util.Collections$EmptyMap) is dropped
+func (t *InstanceMetadata) UnmarshalJSON(data []byte) error {
+    metadata := map[string]string{}
+    err := json.Unmarshal(data, &metadata)
+    if err != nil {
+        return err
+    }
+
+    delete(metadata, "@class")
+    *t = metadata
+    return nil
+}
+
+func (t *InstanceMetadata) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+    metadata := InstanceMetadata{}
+    for {
+        token, err := d.Token()
+        if err != nil {
+            return err
+        }
+
+        switch el := token.(type) {
+        case xml.StartElement:
+            var value string
+            err = d.DecodeElement(&value, &el)
+            if err != nil {
+                return err
+            }
+            metadata[el.Name.Local] = value
+        case xml.EndElement:
+            *t = metadata
+            return nil
+        }
+    }
+}
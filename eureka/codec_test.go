@@ -0,0 +1,152 @@
+package eureka
+
+import (
+    "reflect"
+    "testing"
+)
+
+// registry in XML, as returned by eureka server
+const test_applications_xml = `<applications>
+  <versions__delta>1</versions__delta>
+  <apps__hashcode>UP_1_</apps__hashcode>
+  <application>
+    <name>TEST-APP</name>
+    <instance>
+      <instanceId>10.0.0.1:test-app:8080</instanceId>
+      <hostName>10.0.0.1</hostName>
+      <app>TEST-APP</app>
+      <ipAddr>10.0.0.1</ipAddr>
+      <status>UP</status>
+      <overriddenstatus>UNKNOWN</overriddenstatus>
+      <port enabled="true">8080</port>
+      <securePort enabled="false">443</securePort>
+      <dataCenterInfo class="com.netflix.appinfo.InstanceInfo$DefaultDataCenterInfo">
+        <name>MyOwn</name>
+      </dataCenterInfo>
+      <metadata>
+        <version>1.0</version>
+        <zone>zone-cn-hz-1</zone>
+      </metadata>
+      <vipAddress>test-app</vipAddress>
+      <secureVipAddress>test-app</secureVipAddress>
+      <actionType>ADDED</actionType>
+    </instance>
+  </application>
+</applications>`
+
+func Test_XmlCodecDecodeApplications(t *testing.T) {
+    apps, err := GetCodec(CODEC_XML).DecodeApplications([]byte(test_applications_xml))
+    if err != nil {
+        t.Fatal(err.Error())
+    }
+
+    if apps.AppsHashCode != "UP_1_" || len(apps.Application) != 1 || len(apps.Application[0].Instances) != 1 {
+        t.Fatalf("Unexpected applications: %+v", apps)
+    }
+
+    ins := apps.Application[0].Instances[0]
+    if ins.Port.Value != 8080 || ins.Port.Enabled != "true" || ins.SecurePort.Enabled != "false" {
+        t.Fatalf("Unexpected ports: %+v, %+v", ins.Port, ins.SecurePort)
+    }
+    if ins.DataCenterInfo.Name != DC_NAME_TYPE_MY_OWN || ins.DataCenterInfo.Class == "" {
+        t.Fatalf("Unexpected data center info: %+v", ins.DataCenterInfo)
+    }
+    if ins.Metadata["version"] != "1.0" || ins.Metadata["zone"] != "zone-cn-hz-1" {
+        t.Fatalf("Unexpected metadata: %v", ins.Metadata)
+    }
+}
+
+func Test_CodecRoundTrip(t *testing.T) {
+    vo := DefaultInstanceVo()
+    vo.App = "TEST-APP"
+    vo.InstanceId = "10.0.0.1:test-app:8080"
+    vo.Metadata = map[string]string{"version": "1.0"}
+    vo.CountryId = 1
+    vo.IsCoordinatingDiscoveryServer = "false"
+    vo.LastDirtyTimestamp = 1581000000000
+    vo.LeaseInfo.RegistrationTimestamp = 1581000000001
+    vo.DataCenterInfo = DataCenterInfo{
+        Class:    "com.netflix.appinfo.AmazonInfo",
+        Name:     DC_NAME_TYPE_AMAZON,
+        Metadata: InstanceMetadata{
+            AMAZON_METADATA_INSTANCE_ID:       "i-0123456789",
+            AMAZON_METADATA_AVAILABILITY_ZONE: "us-east-1a",
+            AMAZON_METADATA_LOCAL_IPV4:        "10.0.0.1",
+            // not in AMAZON_METADATA_*
+            "ipv6": "fe80::1",
+        },
+    }
+
+    for _, name := range []string{CODEC_JSON, CODEC_XML} {
+        codec := GetCodec(name)
+        body, err := codec.EncodeInstance(vo)
+        if err != nil {
+            t.Fatal(err.Error())
+        }
+
+        decoded, err := codec.DecodeInstance(body)
+        if err != nil {
+            t.Fatal(err.Error())
+        }
+        if !reflect.DeepEqual(vo, decoded) {
+            t.Fatalf("Codec %s, expected %+v, got %+v", name, vo, decoded)
+        }
+        t.Log(name, ": ", string(body))
+    }
+}
+
+// instance in JSON, as returned by eureka server (Java)
+const test_instance_json = `{"instance": {
+  "instanceId": "10.0.0.1:test-app:8080",
+  "hostName": "10.0.0.1",
+  "app": "TEST-APP",
+  "ipAddr": "10.0.0.1",
+  "status": "UP",
+  "overriddenstatus": "UNKNOWN",
+  "port": {"$": 8080, "@enabled": "true"},
+  "securePort": {"$": 443, "@enabled": "false"},
+  "countryId": 1,
+  "dataCenterInfo": {
+    "@class": "com.netflix.appinfo.AmazonInfo",
+    "name": "Amazon",
+    "metadata": {"instance-id": "i-0123456789", "availability-zone": "us-east-1a", "local-ipv4": "10.0.0.1", "ipv6": "fe80::1"}
+  },
+  "leaseInfo": {
+    "renewalIntervalInSecs": 30,
+    "durationInSecs": 90,
+    "registrationTimestamp": 1581000000001,
+    "lastRenewalTimestamp": 1581000000002,
+    "evictionTimestamp": 0,
+    "serviceUpTimestamp": 1581000000003
+  },
+  "metadata": {"@class": "java.util.Collections$EmptyMap"},
+  "vipAddress": "test-app",
+  "secureVipAddress": "test-app",
+  "isCoordinatingDiscoveryServer": "false",
+  "lastUpdatedTimestamp": "1581000000004",
+  "lastDirtyTimestamp": "1581000000005",
+  "actionType": "ADDED"
+}}`
+
+func Test_JsonCodecDecodeInstance(t *testing.T) {
+    ins, err := GetCodec(CODEC_JSON).DecodeInstance([]byte(test_instance_json))
+    if err != nil {
+        t.Fatal(err.Error())
+    }
+
+    if ins.CountryId != 1 || ins.IsCoordinatingDiscoveryServer != "false" {
+        t.Fatalf("Unexpected instance: %+v", ins)
+    }
+    if ins.LastUpdatedTimestamp != 1581000000004 || ins.LastDirtyTimestamp != 1581000000005 {
+        t.Fatalf("Unexpected timestamps: %d, %d", ins.LastUpdatedTimestamp, ins.LastDirtyTimestamp)
+    }
+    if ins.LeaseInfo.EvictionDurationInSecs != 90 || ins.LeaseInfo.ServiceUpTimestamp != 1581000000003 {
+        t.Fatalf("Unexpected lease info: %+v", ins.LeaseInfo)
+    }
+    if len(ins.Metadata) != 0 {
+        t.Fatalf("Unexpected metadata: %v", ins.Metadata)
+    }
+    if ins.DataCenterInfo.Metadata[AMAZON_METADATA_INSTANCE_ID] != "i-0123456789" || ins.DataCenterInfo.Metadata["ipv6"] != "fe80::1" {
+        t.Fatalf("Unexpected data center info: %+v", ins.DataCenterInfo)
+    }
+}
@@ -0,0 +1,354 @@
+package eureka
+
+import (
+    "bufio"
+    "fmt"
+    "io/ioutil"
+    "os"
+    "path/filepath"
+    "reflect"
+    "sort"
+    "strconv"
+    "strings"
+
+    "gopkg.in/yaml.v2"
+)
+
+const (
+    CONFIG_PREFIX_CLIENT   = "eureka.client."
+    CONFIG_PREFIX_INSTANCE = "eureka.instance."
+
+    CONFIG_KEY_APPLICATION_NAME = "spring.application.name"
+    CONFIG_KEY_SERVER_PORT      = "server.port"
+
+    // prefix of environment variables loaded, e.g: EUREKA_CLIENT_SERVICEURL_DEFAULTZONE
+    CONFIG_ENV_PREFIX = "EUREKA_"
+)
+
+// load Spring Cloud Eureka style configurations from yaml / properties files
+// and environment variables, e.g:
+//     eureka.client.serviceUrl.defaultZone=http://192.168.20.236:9001/eureka
+//     EUREKA_CLIENT_SERVICEURL_DEFAULTZONE=http://192.168.20.236:9001/eureka
+// keys are relaxed like Spring: case insensitive, '-' and '_' ignored, e.g: eureka.client.service-url.defaultZone,
+// values loaded later override earlier ones
+type ConfigLoader struct {
+    // key: relaxed key, e.g: eureka.client.serviceurl.defaultzone
+    props map[string]configProperty
+}
+
+type configProperty struct {
+    // original key, keeps case of map keys, e.g: eureka.client.serviceUrl.zone-cn-hz-1
+    key   string
+    value string
+}
+
+func NewConfigLoader() *ConfigLoader {
+    return &ConfigLoader{
+        props: map[string]configProperty{},
+    }
+}
+
+// load config file, yaml / properties by file extension (.yml, .yaml, .properties),
+// then environment variables which override the file, e.g:
+//     config, instanceConfig, err := eureka.LoadConfig("application.yml")
+func LoadConfig(path string) (*EurekaClientConfig, *EurekaInstanceConfig, error) {
+    loader := NewConfigLoader()
+    err := loader.LoadFile(path)
+    if err != nil {
+        return nil, nil, err
+    }
+    loader.LoadEnv()
+
+    config, err := loader.ClientConfig()
+    if err != nil {
+        return nil, nil, err
+    }
+
+    instance, err := loader.InstanceConfig()
+    if err != nil {
+        return nil, nil, err
+    }
+
+    return config, instance, nil
+}
+
+// set one property, e.g: Set("eureka.client.registerWithEureka", "false")
+func (t *ConfigLoader) Set(key, value string) *ConfigLoader {
+    t.props[relaxedKey(key)] = configProperty{key: key, value: value}
+    return t
+}
+
+// get property by (relaxed) key
+func (t *ConfigLoader) Get(key string) (string, bool) {
+    p, ok := t.props[relaxedKey(key)]
+    return p.value, ok
+}
+
+// load yaml / properties file by file extension
+func (t *ConfigLoader) LoadFile(path string) error {
+    switch strings.ToLower(filepath.Ext(path)) {
+    case ".yml", ".yaml":
+        return t.LoadYaml(path)
+    case ".properties":
+        return t.LoadProperties(path)
+    }
+
+    return fmt.Errorf("Failed to recognize config file type, file=%s", path)
+}
+
+// load yaml file, e.g: application.yml
+func (t *ConfigLoader) LoadYaml(path string) error {
+    data, err := ioutil.ReadFile(path)
+    if err != nil {
+        log.Errorf("Failed to read config file=%s, err=%s", path, err.Error())
+        return err
+    }
+
+    return t.LoadYamlBytes(data)
+}
+
+func (t *ConfigLoader) LoadYamlBytes(data []byte) error {
+    doc := map[interface{}]interface{}{}
+    err := yaml.Unmarshal(data, &doc)
+    if err != nil {
+        log.Errorf("Failed to parse yaml config, err=%s", err.Error())
+        return err
+    }
+
+    t.flattenYaml("", doc)
+    return nil
+}
+
+// flatten yaml nodes into dotted keys, lists are joined by ','
+func (t *ConfigLoader) flattenYaml(prefix string, node interface{}) {
+    switch v := node.(type) {
+    case map[interface{}]interface{}:
+        for k, child := range v {
+            t.flattenYaml(prefix+fmt.Sprint(k)+".", child)
+        }
+    case []interface{}:
+        values := make([]string, 0, len(v))
+        for _, item := range v {
+            values = append(values, fmt.Sprint(item))
+        }
+        t.Set(strings.TrimSuffix(prefix, "."), strings.Join(values, ","))
+    case nil:
+        t.Set(strings.TrimSuffix(prefix, "."), "")
+    default:
+        t.Set(strings.TrimSuffix(prefix, "."), fmt.Sprint(v))
+    }
+}
+
+// load properties file, e.g: application.properties
+func (t *ConfigLoader) LoadProperties(path string) error {
+    data, err := ioutil.ReadFile(path)
+    if err != nil {
+        log.Errorf("Failed to read config file=%s, err=%s", path, err.Error())
+        return err
+    }
+
+    return t.LoadPropertiesBytes(data)
+}
+
+// lines of key=value (or key: value), '#' and '!' start comment lines,
+// line ends with '\' continues on next line
+func (t *ConfigLoader) LoadPropertiesBytes(data []byte) error {
+    scanner := bufio.NewScanner(strings.NewReader(string(data)))
+    line := ""
+    for scanner.Scan() {
+        text := strings.TrimSpace(scanner.Text())
+        if line == "" && (text == "" || strings.HasPrefix(text, "#") || strings.HasPrefix(text, "!")) {
+            continue
+        }
+
+        if strings.HasSuffix(text, "\\") {
+            line += strings.TrimSuffix(text, "\\")
+            continue
+        }
+        line += text
+
+        i := strings.IndexAny(line, "=:")
+        if i < 0 {
+            t.Set(line, "")
+        } else {
+            t.Set(strings.TrimSpace(line[:i]), strings.TrimSpace(line[i+1:]))
+        }
+        line = ""
+    }
+
+    return scanner.Err()
+}
+
+// load environment variables EUREKA_*, SPRING_APPLICATION_NAME and SERVER_PORT,
+// '_' separates keys, e.g: EUREKA_CLIENT_SERVICEURL_DEFAULTZONE -> eureka.client.serviceurl.defaultzone,
+// the original key of property already loaded is kept while overridden, e.g:
+// EUREKA_CLIENT_SERVICEURL_US_EAST_1C overrides eureka.client.serviceUrl.us-east-1c -> ServiceUrl["us-east-1c"]
+func (t *ConfigLoader) LoadEnv() *ConfigLoader {
+    for _, env := range os.Environ() {
+        i := strings.Index(env, "=")
+        if i < 0 {
+            continue
+        }
+
+        name := strings.ToUpper(env[:i])
+        key := strings.ToLower(strings.Replace(name, "_", ".", -1))
+        if strings.HasPrefix(name, CONFIG_ENV_PREFIX) || key == CONFIG_KEY_APPLICATION_NAME || key == CONFIG_KEY_SERVER_PORT {
+            t.Set(t.envKey(key), env[i+1:])
+        }
+    }
+
+    return t
+}
+
+// original key of property loaded matching key from env, key itself while not found,
+// '.' is ignored as well because '-' of original key is '_' (so '.') in env, e.g: us.east.1c -> us-east-1c
+func (t *ConfigLoader) envKey(key string) string {
+    envKey := strings.Replace(relaxedKey(key), ".", "", -1)
+    for k, p := range t.props {
+        if strings.Replace(k, ".", "", -1) == envKey {
+            return p.key
+        }
+    }
+
+    return key
+}
+
+// eureka client config, defaults from GetDefaultEurekaClientConfig()
+// overridden by eureka.client.* properties, e.g: eureka.client.serviceUrl.defaultZone -> ServiceUrl["defaultZone"]
+func (t *ConfigLoader) ClientConfig() (*EurekaClientConfig, error) {
+    config := GetDefaultEurekaClientConfig()
+    err := t.bind(CONFIG_PREFIX_CLIENT, config)
+    if err != nil {
+        return nil, err
+    }
+
+    return config, nil
+}
+
+// instance config, defaults from GetDefaultEurekaInstanceConfig()
+// overridden by eureka.instance.* properties, e.g: eureka.instance.metadataMap.version -> MetadataMap["version"],
+// appname default: spring.application.name, nonSecurePort default: server.port
+func (t *ConfigLoader) InstanceConfig() (*EurekaInstanceConfig, error) {
+    config := GetDefaultEurekaInstanceConfig()
+    config.Appname = t.first(CONFIG_KEY_APPLICATION_NAME)
+    if v := t.first(CONFIG_KEY_SERVER_PORT); v != "" {
+        port, err := strconv.Atoi(v)
+        if err != nil {
+            return nil, fmt.Errorf("Invalid config %s=%s, err=%s", CONFIG_KEY_SERVER_PORT, v, err.Error())
+        }
+        config.NonSecurePort = port
+    }
+
+    err := t.bind(CONFIG_PREFIX_INSTANCE, config)
+    if err != nil {
+        return nil, err
+    }
+
+    return config, nil
+}
+
+// value of the first key found
+func (t *ConfigLoader) first(keys ...string) string {
+    for _, key := range keys {
+        if v, ok := t.Get(key); ok {
+            return v
+        }
+    }
+
+    return ""
+}
+
+// set exported fields of struct pointer target from properties under prefix,
+// property name (relaxed) matches field name, e.g: eureka.client.register-with-eureka -> RegisterWithEureka,
+// map fields take the rest of key as map key, e.g: eureka.client.serviceUrl.defaultZone -> ServiceUrl["defaultZone"]
+func (t *ConfigLoader) bind(prefix string, target interface{}) error {
+    v := reflect.ValueOf(target).Elem()
+
+    // key: relaxed field name
+    // value: field index
+    index := map[string]int{}
+    for i := 0; i < v.NumField(); i++ {
+        if v.Type().Field(i).PkgPath != "" {
+            continue
+        }
+        index[relaxedKey(v.Type().Field(i).Name)] = i
+    }
+
+    relaxedPrefix := relaxedKey(prefix)
+    keys := make([]string, 0, len(t.props))
+    for k := range t.props {
+        if strings.HasPrefix(k, relaxedPrefix) {
+            keys = append(keys, k)
+        }
+    }
+    sort.Strings(keys)
+
+    depth := strings.Count(prefix, ".")
+    for _, k := range keys {
+        p := t.props[k]
+        segs := strings.Split(k, ".")[depth:]
+        origSegs := strings.Split(p.key, ".")[depth:]
+
+        // keys from env are split by '_' as well, e.g: register.with.eureka -> registerwitheureka
+        for n := 1; n <= len(segs); n++ {
+            i, ok := index[strings.Join(segs[:n], "")]
+            if !ok {
+                continue
+            }
+
+            err := setField(v.Field(i), strings.Join(origSegs[n:], "."), p.value)
+            if err != nil {
+                return fmt.Errorf("Invalid config %s=%s, err=%s", p.key, p.value, err.Error())
+            }
+            break
+        }
+    }
+
+    return nil
+}
+
+// set field from string value, mapKey is only for map field
+func setField(field reflect.Value, mapKey, value string) error {
+    switch field.Kind() {
+    case reflect.String:
+        if mapKey == "" {
+            field.SetString(value)
+        }
+    case reflect.Int:
+        if mapKey == "" {
+            i, err := strconv.Atoi(value)
+            if err != nil {
+                return err
+            }
+            field.SetInt(int64(i))
+        }
+    case reflect.Bool:
+        if mapKey == "" {
+            b, err := strconv.ParseBool(value)
+            if err != nil {
+                return err
+            }
+            field.SetBool(b)
+        }
+    case reflect.Map:
+        if mapKey == "" || field.Type().Key().Kind() != reflect.String || field.Type().Elem().Kind() != reflect.String {
+            return nil
+        }
+        if strings.EqualFold(mapKey, DEFAULT_ZONE) {
+            mapKey = DEFAULT_ZONE
+        }
+        if field.IsNil() {
+            field.Set(reflect.MakeMap(field.Type()))
+        }
+        field.SetMapIndex(reflect.ValueOf(mapKey).Convert(field.Type().Key()), reflect.ValueOf(value).Convert(field.Type().Elem()))
+    }
+
+    return nil
+}
+
+// relaxed key like Spring, e.g: eureka.client.service-url.defaultZone -> eureka.client.serviceurl.defaultzone
+func relaxedKey(key string) string {
+    key = strings.ToLower(key)
+    key = strings.Replace(key, "-", "", -1)
+    return strings.Replace(key, "_", "", -1)
+}
@@ -0,0 +1,151 @@
+package eureka
+
+import (
+    "testing"
+)
+
+const test_config_yaml = `
+spring:
+  application:
+    name: test-app
+server:
+  port: 9000
+eureka:
+  client:
+    register-with-eureka: false
+    registryFetchIntervalSeconds: 10
+    region: region-cn-hd-1
+    availability-zones:
+      region-cn-hd-1: zone-cn-hz-1,zone-cn-hz-2
+    serviceUrl:
+      defaultZone: http://192.168.20.236:9001/eureka
+      zone-cn-hz-1: http://192.168.20.237:9001/eureka
+  instance:
+    prefer-ip-address: true
+    ip-address: 10.0.0.1
+    lease-expiration-duration-in-seconds: 60
+    status-page-url-path: /actuator/info
+    metadata-map:
+      version: "1.0"
+      zone: zone-cn-hz-1
+`
+
+const test_config_properties = `
+# comment
+spring.application.name=test-app
+eureka.client.fetchRegistry=false
+eureka.client.serviceUrl.defaultZone: http://192.168.20.236:9001/eureka,\
+  http://192.168.20.237:9001/eureka
+eureka.instance.nonSecurePort=9001
+eureka.instance.metadataMap.version=2.0
+`
+
+func Test_ConfigLoaderYaml(t *testing.T) {
+    loader := NewConfigLoader()
+    err := loader.LoadYamlBytes([]byte(test_config_yaml))
+    if err != nil {
+        t.Fatal(err.Error())
+    }
+
+    config, err := loader.ClientConfig()
+    if err != nil {
+        t.Fatal(err.Error())
+    }
+    if config.RegisterWithEureka || !config.FetchRegistry || config.RegistryFetchIntervalSeconds != 10 || config.Region != "region-cn-hd-1" {
+        t.Fatalf("Unexpected config: %+v", config)
+    }
+    if config.ServiceUrl[DEFAULT_ZONE] != "http://192.168.20.236:9001/eureka" || config.ServiceUrl["zone-cn-hz-1"] != "http://192.168.20.237:9001/eureka" {
+        t.Fatalf("Unexpected service urls: %v", config.ServiceUrl)
+    }
+    if config.AvailabilityZones["region-cn-hd-1"] != "zone-cn-hz-1,zone-cn-hz-2" {
+        t.Fatalf("Unexpected availability zones: %v", config.AvailabilityZones)
+    }
+
+    instanceConfig, err := loader.InstanceConfig()
+    if err != nil {
+        t.Fatal(err.Error())
+    }
+    vo, err := instanceConfig.InstanceVo()
+    if err != nil {
+        t.Fatal(err.Error())
+    }
+    if vo.App != "test-app" || vo.Port.Value != 9000 || vo.Hostname != "10.0.0.1" || vo.LeaseInfo.EvictionDurationInSecs != 60 {
+        t.Fatalf("Unexpected instance: %+v", vo)
+    }
+    if vo.StatusPageUrl != "http://10.0.0.1:9000/actuator/info" {
+        t.Fatalf("Unexpected status page url: %s", vo.StatusPageUrl)
+    }
+    if vo.Metadata["version"] != "1.0" || vo.Metadata["zone"] != "zone-cn-hz-1" {
+        t.Fatalf("Unexpected metadata: %v", vo.Metadata)
+    }
+}
+
+func Test_ConfigLoaderPropertiesAndEnv(t *testing.T) {
+    t.Setenv("EUREKA_CLIENT_REGISTER_WITH_EUREKA", "false")
+    t.Setenv("EUREKA_CLIENT_SERVICEURL_DEFAULTZONE", "http://192.168.20.238:9001/eureka")
+    t.Setenv("EUREKA_INSTANCE_METADATAMAP_CANARY", "true")
+
+    loader := NewConfigLoader()
+    err := loader.LoadPropertiesBytes([]byte(test_config_properties))
+    if err != nil {
+        t.Fatal(err.Error())
+    }
+
+    config, err := loader.ClientConfig()
+    if err != nil {
+        t.Fatal(err.Error())
+    }
+    if config.FetchRegistry || config.ServiceUrl[DEFAULT_ZONE] != "http://192.168.20.236:9001/eureka,http://192.168.20.237:9001/eureka" {
+        t.Fatalf("Unexpected config: %+v", config)
+    }
+
+    // environment variables override properties
+    loader.LoadEnv()
+    config, err = loader.ClientConfig()
+    if err != nil {
+        t.Fatal(err.Error())
+    }
+    if config.RegisterWithEureka || config.FetchRegistry || config.ServiceUrl[DEFAULT_ZONE] != "http://192.168.20.238:9001/eureka" {
+        t.Fatalf("Unexpected config: %+v", config)
+    }
+
+    instanceConfig, err := loader.InstanceConfig()
+    if err != nil {
+        t.Fatal(err.Error())
+    }
+    vo, err := instanceConfig.InstanceVo()
+    if err != nil {
+        t.Fatal(err.Error())
+    }
+    if vo.App != "test-app" || vo.Port.Value != 9001 || vo.Metadata["version"] != "2.0" || vo.Metadata["canary"] != "true" {
+        t.Fatalf("Unexpected instance: %+v", vo)
+    }
+}
+
+func Test_ConfigLoaderEnvKeepsKey(t *testing.T) {
+    t.Setenv("EUREKA_CLIENT_SERVICEURL_MYZONE", "http://192.168.20.238:9001/eureka")
+    t.Setenv("EUREKA_CLIENT_SERVICEURL_ZONE_CN_HZ_1", "http://192.168.20.239:9001/eureka")
+
+    loader := NewConfigLoader()
+    err := loader.LoadYamlBytes([]byte(test_config_yaml))
+    if err != nil {
+        t.Fatal(err.Error())
+    }
+    loader.Set("eureka.client.serviceUrl.myZone", "http://192.168.20.236:9001/eureka")
+
+    config, err := loader.LoadEnv().ClientConfig()
+    if err != nil {
+        t.Fatal(err.Error())
+    }
+    if len(config.ServiceUrl) != 3 || config.ServiceUrl["myZone"] != "http://192.168.20.238:9001/eureka" ||
+        config.ServiceUrl["zone-cn-hz-1"] != "http://192.168.20.239:9001/eureka" {
+        t.Fatalf("Unexpected service urls: %v", config.ServiceUrl)
+    }
+}
+
+func Test_ConfigLoaderInvalidValue(t *testing.T) {
+    _, err := NewConfigLoader().Set("eureka.client.registryFetchIntervalSeconds", "ten").ClientConfig()
+    if err == nil {
+        t.Fatal("Invalid int value should fail")
+    }
+}
@@ -3,4 +3,8 @@ package eureka
 type DiscoveryClient interface {
     GetRegistryApps() map[string]ApplicationVo
     GetInstance() *InstanceVo
+    GetInstancesByAppId(appId string) []InstanceVo
+    GetInstancesByVipAddress(vipAddress string, secure bool) []InstanceVo
+    ChooseInstance(appId string) (*InstanceVo, error)
+    ChooseInstanceUrl(appId string) (string, error)
 }
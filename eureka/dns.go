@@ -8,6 +8,10 @@ import (
     "errors"
 )
 
+// DNS servers to lookup TXT records of eureka servers from, e.g: "192.168.20.238:53",
+// default (empty): servers in /etc/resolv.conf
+var dnsServerAddrs []string
+
 // golang's net.LookupTXT has "bug" (comments below), so here use miekg/dns to implement lookupTxt()
 // Refer to net.LookupTXT():
 // Multiple strings in one TXT record need to be
@@ -22,6 +26,8 @@ func lookupTXT(domain string, dnsAddr ...string) ([]string, time.Duration, error
     domain = strings.TrimRight(domain, ".") + "."
 
     if len(dnsAddr) > 0 {
+        // ports appended to a copy, dnsAddr may be dnsServerAddrs
+        dnsAddr = append([]string{}, dnsAddr...)
         for i, _ := range dnsAddr {
             if !strings.Contains(dnsAddr[i], ":") { // validate whether contains port
                 dnsAddr[i] += ":53"
@@ -18,6 +18,15 @@ func (t *EndpointUtils) GetDiscoveryServiceUrls(config *EurekaClientConfig, zone
 
 }
 
+// eureka service urls of (remote) region, refer to FetchRemoteRegionsRegistry
+func (t *EndpointUtils) GetRegionServiceUrls(config *EurekaClientConfig, region string) ([]string, error) {
+    if config.UseDnsForFetchingServiceUrls {
+        return t.getServiceUrlsFromDNS(config, region, "")
+    }
+
+    return t.getServiceUrlsFromConfig(config, region, "")
+}
+
 /**
  * Get the zone based CNAMES that are bound to a region.
  *
@@ -28,7 +37,7 @@ func (t *EndpointUtils) GetDiscoveryServiceUrls(config *EurekaClientConfig, zone
  */
 func (t *EndpointUtils) getZoneBasedDiscoveryUrlsFromRegion(config *EurekaClientConfig, region string) (map[string][]string, error) {
     discoveryDnsName := fmt.Sprintf("txt.%s.%s", region, config.EurekaServerDNSName)
-    zoneCNames, _, err := lookupTXT(discoveryDnsName)
+    zoneCNames, _, err := lookupTXT(discoveryDnsName, dnsServerAddrs...)
     if err != nil {
         log.Errorf("LookupTXT failed, err=%s", err.Error())
         return nil, err
@@ -67,7 +76,11 @@ func (t *EndpointUtils) GetServiceUrlsMapFromConfig(config *EurekaClientConfig,
  * @return The list of all eureka service urls for the eureka client to talk to.
  */
 func (t *EndpointUtils) GetServiceUrlsFromDNS(config *EurekaClientConfig, instanceZone string) ([]string, error) {
-    zoneCnameSets, err := t.getZoneBasedDiscoveryUrlsFromRegion(config, config.GetRegion())
+    return t.getServiceUrlsFromDNS(config, config.GetRegion(), instanceZone)
+}
+
+func (t *EndpointUtils) getServiceUrlsFromDNS(config *EurekaClientConfig, region, instanceZone string) ([]string, error) {
+    zoneCnameSets, err := t.getZoneBasedDiscoveryUrlsFromRegion(config, region)
     if err != nil {
         return nil, err
     }
@@ -82,7 +95,7 @@ func (t *EndpointUtils) GetServiceUrlsFromDNS(config *EurekaClientConfig, instan
     for zone, cnames := range zoneCnameSets {
         for _, cname := range cnames {
             dnsName := fmt.Sprintf("txt.%s", cname)
-            records, _, err := lookupTXT(dnsName)
+            records, _, err := lookupTXT(dnsName, dnsServerAddrs...)
             if err != nil {
                 log.Errorf("LookupTXT failed, dnsName=%s, err=%s", dnsName, err.Error())
                 return nil, err
@@ -95,19 +108,29 @@ func (t *EndpointUtils) GetServiceUrlsFromDNS(config *EurekaClientConfig, instan
         }
     }
 
-    // while same zone eureka exist
+    // instance zone first while same zone eureka exist, then other zones randomly
+    zones := make([]string, 0, len(zoneServiceUrls))
     if _, ok := zoneServiceUrls[instanceZone]; ok && config.PreferSameZoneEureka {
-        return t.formatUrls(config, zoneServiceUrls[instanceZone]), nil
+        zones = append(zones, instanceZone)
+    }
+    for zone, _ := range zoneServiceUrls {
+        if len(zones) > 0 && zone == zones[0] {
+            continue
+        }
+        zones = append(zones, zone)
     }
 
-    // return service urls randomly
-    for _, urls := range zoneServiceUrls {
-        return t.formatUrls(config, urls), nil
+    urls := make([]string, 0)
+    for _, zone := range zones {
+        urls = append(urls, t.formatUrls(config, zoneServiceUrls[zone])...)
     }
 
-    err = errors.New("Fail to match service urls.")
-    log.Errorf(err.Error())
-    return nil, err
+    if len(urls) == 0 {
+        err = errors.New("Fail to match service urls.")
+        log.Errorf(err.Error())
+        return nil, err
+    }
+    return urls, nil
 }
 
 func (t *EndpointUtils) formatUrls(config *EurekaClientConfig, urls []string) []string {
@@ -126,8 +149,25 @@ func (t *EndpointUtils) formatUrls(config *EurekaClientConfig, urls []string) []
  * @return The list of all eureka service urls for the eureka client to talk to
  */
 func (t *EndpointUtils) GetServiceUrlsFromConfig(config *EurekaClientConfig, instanceZone string) ([]string, error) {
-    availZones := config.GetAvailabilityZones(config.GetRegion())
-    log.Debugf("The availability zone for the given region %s are %v ", config.GetRegion(), availZones)
+    return t.getServiceUrlsFromConfig(config, config.GetRegion(), instanceZone)
+}
+
+func (t *EndpointUtils) getServiceUrlsFromConfig(config *EurekaClientConfig, region, instanceZone string) ([]string, error) {
+    availZones := config.GetAvailabilityZones(region)
+    log.Debugf("The availability zone for the given region %s are %v ", region, availZones)
+
+    // instance zone first (then the zones after it, wrapping around),
+    // so that eureka servers in the same zone are tried first
+    if config.PreferSameZoneEureka {
+        for i, zone := range availZones {
+            if zone == instanceZone {
+                ordered := make([]string, 0, len(availZones))
+                ordered = append(ordered, availZones[i:]...)
+                availZones = append(ordered, availZones[:i]...)
+                break
+            }
+        }
+    }
 
     urls := make([]string, 0)
     for _, zone := range availZones {
@@ -0,0 +1,103 @@
+package eureka
+
+import (
+    "errors"
+    "fmt"
+    "net/http"
+    "strings"
+)
+
+var (
+    // http 404 from eureka server, e.g: instance not known by eureka server,
+    // errors.Is(err, ErrNotFound) matches *HTTPError with status code 404
+    ErrNotFound = errors.New("Not found in eureka server")
+
+    // no eureka server service url is configured or resolved
+    ErrNoServiceUrl = errors.New("No service url is available to pick.")
+
+    // no instance of app in local registry
+    ErrNoInstance = errors.New("No instance is available to choose")
+)
+
+// error of http response with non 2xx status code
+type HTTPError struct {
+    StatusCode int
+    Body       string
+}
+
+func (e *HTTPError) Error() string {
+    return fmt.Sprintf("Request failed, Http status code: %d, body: %s", e.StatusCode, e.Body)
+}
+
+// errors.Is(err, ErrNotFound) while status code is 404
+func (e *HTTPError) Is(target error) bool {
+    return target == ErrNotFound && e.StatusCode == http.StatusNotFound
+}
+
+// error before response received, e.g: connection refused, timeout
+type TransportError struct {
+    Method string
+    Url    string
+    Err    error
+}
+
+func (e *TransportError) Error() string {
+    return fmt.Sprintf("Request failed, %s %s, err=%s", e.Method, e.Url, e.Err.Error())
+}
+
+func (e *TransportError) Unwrap() error {
+    return e.Err
+}
+
+// error of decoding response body
+type DecodeError struct {
+    Body string
+    Err  error
+}
+
+func (e *DecodeError) Error() string {
+    return fmt.Sprintf("Failed to decode response, err=%s, body: %s", e.Err.Error(), e.Body)
+}
+
+func (e *DecodeError) Unwrap() error {
+    return e.Err
+}
+
+// invalid field of config, e.g: RegistryFetchIntervalSeconds=0
+type FieldError struct {
+    Field   string
+    Message string
+}
+
+func (e *FieldError) Error() string {
+    return e.Field + ": " + e.Message
+}
+
+// all invalid fields found by validating config, e.g: EurekaClientConfig.Validate(),
+// use errors.As(err, &configErr) to inspect fields
+type ConfigError struct {
+    // name of config, e.g: EurekaClientConfig
+    Config string
+    Errors []*FieldError
+}
+
+func (e *ConfigError) Error() string {
+    msgs := make([]string, 0, len(e.Errors))
+    for _, fe := range e.Errors {
+        msgs = append(msgs, fe.Error())
+    }
+    return fmt.Sprintf("Invalid %s, %s", e.Config, strings.Join(msgs, "; "))
+}
+
+// add field error, e.g: add("RegistryFetchIntervalSeconds", "should be greater than 0, got %d", 0)
+func (e *ConfigError) add(field, format string, args ...interface{}) {
+    e.Errors = append(e.Errors, &FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+// nil while no field error, keeps `return err` of Validate() comparable to nil
+func (e *ConfigError) errOrNil() error {
+    if len(e.Errors) == 0 {
+        return nil
+    }
+    return e
+}
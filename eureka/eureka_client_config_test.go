@@ -1,16 +1,68 @@
 package eureka
 
 import (
-    "testing"
+    "errors"
+    "net"
     "strings"
+    "testing"
+    "time"
+
+    "github.com/miekg/dns"
 )
 
+// TXT records served by local DNS server, key: fqdn
+var test_txt_records = map[string][]string{
+    "txt.region-cn-hd-1.dev.ms-registry.xf.io.": {"zone-cn-hz-1.dev.ms-registry.xf.io", "zone-cn-hz-2.dev.ms-registry.xf.io"},
+    "txt.zone-cn-hz-1.dev.ms-registry.xf.io.":   {"192.168.20.236", "192.168.20.237"},
+    "txt.zone-cn-hz-2.dev.ms-registry.xf.io.":   {"192.168.20.238"},
+}
+
+// start local DNS server serving TXT records, and lookup TXT records from it (instead of /etc/resolv.conf)
+// till test finished
+func startTestDnsServer(t *testing.T, records map[string][]string) string {
+    conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+    if err != nil {
+        t.Fatal(err.Error())
+    }
+
+    started := make(chan struct{})
+    server := &dns.Server{
+        PacketConn:        conn,
+        NotifyStartedFunc: func() { close(started) },
+        Handler: dns.HandlerFunc(func(w dns.ResponseWriter, req *dns.Msg) {
+            res := new(dns.Msg)
+            res.SetReply(req)
+            name := req.Question[0].Name
+            if txt, ok := records[name]; ok {
+                res.Answer = append(res.Answer, &dns.TXT{
+                    Hdr: dns.RR_Header{Name: name, Rrtype: dns.TypeTXT, Class: dns.ClassINET, Ttl: 60},
+                    Txt: txt,
+                })
+            }
+            w.WriteMsg(res)
+        }),
+    }
+    go server.ActivateAndServe()
+    <-started
+
+    addr := conn.LocalAddr().String()
+    dnsServerAddrs = []string{addr}
+    t.Cleanup(func() {
+        dnsServerAddrs = nil
+        server.Shutdown()
+    })
+    return addr
+}
+
 func Test_LookupTXT(t *testing.T) {
-    records, duration, err := lookupTXT("txt.zone-cn-hz-1.dev.ms-registry.xf.io")
+    addr := startTestDnsServer(t, test_txt_records)
+    records, duration, err := lookupTXT("txt.zone-cn-hz-1.dev.ms-registry.xf.io", addr)
     if err != nil {
         t.Fatal(err.Error())
     }
-    t.Log("Records: ", records, duration)
+    if strings.Join(records, ",") != "192.168.20.236,192.168.20.237" || duration != 60*time.Second {
+        t.Fatalf("Unexpected records: %v, ttl: %s", records, duration)
+    }
 }
 
 func getTestDnsEurekaConfig() *EurekaClientConfig {
@@ -42,6 +94,7 @@ func getTestConfiguredEurekaConfig() *EurekaClientConfig {
 
 // Get service urls by dns
 func Test_GetServiceUrlsByDns(t *testing.T) {
+    startTestDnsServer(t, test_txt_records)
     config := getTestDnsEurekaConfig()
     endpointUtils := new(EndpointUtils)
     urls, err := endpointUtils.GetDiscoveryServiceUrls(config, "zone-cn-hz-1")
@@ -49,7 +102,21 @@ func Test_GetServiceUrlsByDns(t *testing.T) {
         t.Fatal(err.Error())
     }
 
-    t.Log("Eureka server urls: ", strings.Join(urls, ","))
+    expected := "http://192.168.20.236:9001/eureka,http://192.168.20.237:9001/eureka,http://192.168.20.238:9001/eureka"
+    if strings.Join(urls, ",") != expected {
+        t.Fatalf("Expected %s, got %v", expected, urls)
+    }
+
+    // same zone first
+    urls, err = endpointUtils.GetDiscoveryServiceUrls(config, "zone-cn-hz-2")
+    if err != nil {
+        t.Fatal(err.Error())
+    }
+
+    expected = "http://192.168.20.238:9001/eureka,http://192.168.20.236:9001/eureka,http://192.168.20.237:9001/eureka"
+    if strings.Join(urls, ",") != expected {
+        t.Fatalf("Expected %s, got %v", expected, urls)
+    }
 }
 
 // Get service urls by config
@@ -63,3 +130,60 @@ func Test_GetServiceUrlsByConfig(t *testing.T) {
 
     t.Log("Eureka server urls: ", strings.Join(urls, ","))
 }
+
+// Get service urls by config, same zone first
+func Test_GetServiceUrlsByConfigZoneOrder(t *testing.T) {
+    config := getTestConfiguredEurekaConfig()
+    config.AvailabilityZones = map[string]string{
+        "region-cn-hd-1": "zone-cn-hz-1,zone-cn-hz-2,zone-cn-hz-3",
+    }
+    config.ServiceUrl = map[string]string{
+        "zone-cn-hz-1": "http://192.168.20.236:9001/eureka",
+        "zone-cn-hz-2": "http://192.168.20.237:9001/eureka",
+        "zone-cn-hz-3": "http://192.168.20.238:9001/eureka",
+    }
+
+    endpointUtils := new(EndpointUtils)
+    urls, err := endpointUtils.GetDiscoveryServiceUrls(config, "zone-cn-hz-2")
+    if err != nil {
+        t.Fatal(err.Error())
+    }
+
+    expected := "http://192.168.20.237:9001/eureka,http://192.168.20.238:9001/eureka,http://192.168.20.236:9001/eureka"
+    if strings.Join(urls, ",") != expected {
+        t.Fatalf("Expected %s, got %s", expected, strings.Join(urls, ","))
+    }
+}
+
+func Test_EurekaClientConfigValidate(t *testing.T) {
+    if err := GetDefaultEurekaClientConfig().Validate(); err != nil {
+        t.Fatalf("Default config should be valid, got %s", err.Error())
+    }
+
+    config := GetDefaultEurekaClientConfig()
+    config.Region = "region-cn-hd-1"
+    config.AvailabilityZones = map[string]string{"region-cn-hd-1": "zone-cn-hz-1"}
+    config.RegistryFetchIntervalSeconds = 0
+    config.HeartbeatIntervals = 90
+
+    err := config.Validate()
+    configErr := &ConfigError{}
+    if !errors.As(err, &configErr) {
+        t.Fatalf("Expected *ConfigError, got %v", err)
+    }
+    fields := make([]string, 0)
+    for _, fe := range configErr.Errors {
+        fields = append(fields, fe.Field)
+    }
+    expected := "ServiceUrl,RegistryFetchIntervalSeconds,HeartbeatIntervals"
+    if strings.Join(fields, ",") != expected {
+        t.Fatalf("Expected fields %s, got %s", expected, err.Error())
+    }
+
+    config = GetDefaultEurekaClientConfig()
+    config.UseDnsForFetchingServiceUrls = true
+    err = config.Validate()
+    if err == nil || !strings.Contains(err.Error(), "EurekaServerDNSName") {
+        t.Fatalf("Expected EurekaServerDNSName reported, got %v", err)
+    }
+}
@@ -0,0 +1,257 @@
+package eureka
+
+import (
+    "fmt"
+    "strconv"
+    "strings"
+)
+
+const (
+    // placeholders of InstanceId, e.g: {hostname}:{appname}:{port}
+    DEFAULT_INSTANCE_ID_TEMPLATE = "{hostname}:{appname}:{port}"
+)
+
+// refer to:
+//https://github.com/spring-cloud/spring-cloud-netflix/blob/master/spring-cloud-netflix-eureka-client/src/main/java/org/springframework/cloud/netflix/eureka/EurekaInstanceConfigBean.java
+type EurekaInstanceConfig struct {
+    /**
+     * Get the name of the application to be registered with eureka.
+     */
+    Appname string
+
+    /**
+     * Get the name of the application group to be registered with eureka.
+     */
+    AppGroupName string
+
+    /**
+     * Get the unique Id (within the scope of the appName) of this instance to be registered with eureka.
+     * Placeholders: {hostname}, {ipAddress}, {appname}, {port}, {securePort},
+     * default: {hostname}:{appname}:{port}
+     */
+    InstanceId string
+
+    /**
+     * Get the non-secure port on which the instance should receive traffic.
+     */
+    NonSecurePort int
+
+    /**
+     * Get the Secure port on which the instance should receive traffic.
+     */
+    SecurePort int
+
+    /**
+     * Flag to say that non-secure port should be enabled for traffic or not.
+     */
+    NonSecurePortEnabled bool
+
+    /**
+     * Flag to say that secure port should be enabled for traffic or not.
+     */
+    SecurePortEnabled bool
+
+    /**
+     * Indicates how often (in seconds) the eureka client needs to send heartbeats to
+     * eureka server to indicate that it is still alive.
+     */
+    LeaseRenewalIntervalInSeconds int
+
+    /**
+     * Indicates the time in seconds that the eureka server waits since it received the
+     * last heartbeat before it can remove this instance from its view and there by
+     * disallowing traffic to this instance.
+     */
+    LeaseExpirationDurationInSeconds int
+
+    /**
+     * Gets the virtual host name defined for this instance, default: lower case of Appname
+     */
+    VirtualHostName string
+
+    /**
+     * Gets the secure virtual host name defined for this instance, default: lower case of Appname
+     */
+    SecureVirtualHostName string
+
+    /**
+     * Gets the AWS autoscaling group name associated with this instance.
+     */
+    ASGName string
+
+    /**
+     * Gets the metadata name/value pairs associated with this instance.
+     */
+    MetadataMap map[string]string
+
+    /**
+     * Get the IPAdress of the instance, default: first non-loopback ip
+     */
+    IpAddress string
+
+    /**
+     * The hostname if it can be determined at configuration time (otherwise it will be
+     * guessed from OS primitives), default: IpAddress
+     */
+    Hostname string
+
+    /**
+     * Flag to say that, when guessing a hostname, the IP address of the server should be
+     * used in prference to the hostname reported by the OS.
+     */
+    PreferIpAddress bool
+
+    /**
+     * Gets the relative status page URL path for this instance. The status page URL is
+     * then constructed out of the hostName and the type of communication - secure or
+     * unsecure as specified in securePort and nonSecurePort.
+     */
+    StatusPageUrlPath string
+    StatusPageUrl     string
+
+    /**
+     * Gets the relative home page URL Path for this instance.
+     */
+    HomePageUrlPath string
+    HomePageUrl     string
+
+    /**
+     * Gets the relative health check URL path for this instance.
+     */
+    HealthCheckUrlPath   string
+    HealthCheckUrl       string
+    SecureHealthCheckUrl string
+}
+
+// get default instance config
+func GetDefaultEurekaInstanceConfig() *EurekaInstanceConfig {
+    return &EurekaInstanceConfig{
+        InstanceId:                       DEFAULT_INSTANCE_ID_TEMPLATE,
+        NonSecurePort:                    8080,
+        SecurePort:                       443,
+        NonSecurePortEnabled:             true,
+        SecurePortEnabled:                false,
+        LeaseRenewalIntervalInSeconds:    30,
+        LeaseExpirationDurationInSeconds: 90,
+        MetadataMap:                      map[string]string{},
+        IpAddress:                        getLocalIp(),
+        PreferIpAddress:                  false,
+        StatusPageUrlPath:                "/info",
+        HomePageUrlPath:                  "/",
+        HealthCheckUrlPath:               "/health",
+    }
+}
+
+// validate instance config, all invalid fields are reported as *ConfigError
+func (t *EurekaInstanceConfig) Validate() error {
+    errs := &ConfigError{Config: "EurekaInstanceConfig"}
+    if t.Appname == "" {
+        errs.add("Appname", "is required")
+    }
+    if !t.NonSecurePortEnabled && !t.SecurePortEnabled {
+        errs.add("NonSecurePortEnabled", "at least one of NonSecurePortEnabled and SecurePortEnabled should be true")
+    }
+    if t.NonSecurePortEnabled && (t.NonSecurePort <= 0 || t.NonSecurePort > 65535) {
+        errs.add("NonSecurePort", "invalid port %d", t.NonSecurePort)
+    }
+    if t.SecurePortEnabled && (t.SecurePort <= 0 || t.SecurePort > 65535) {
+        errs.add("SecurePort", "invalid port %d", t.SecurePort)
+    }
+    if t.LeaseRenewalIntervalInSeconds <= 0 {
+        errs.add("LeaseRenewalIntervalInSeconds", "should be greater than 0, got %d", t.LeaseRenewalIntervalInSeconds)
+    }
+    if t.LeaseExpirationDurationInSeconds <= t.LeaseRenewalIntervalInSeconds {
+        errs.add("LeaseExpirationDurationInSeconds", "should be greater than LeaseRenewalIntervalInSeconds=%d, got %d",
+            t.LeaseRenewalIntervalInSeconds, t.LeaseExpirationDurationInSeconds)
+    }
+
+    return errs.errOrNil()
+}
+
+// hostname registered with, IpAddress while PreferIpAddress is true or Hostname is empty
+func (t *EurekaInstanceConfig) GetHostname() string {
+    if t.PreferIpAddress || t.Hostname == "" {
+        return t.IpAddress
+    }
+
+    return t.Hostname
+}
+
+// build InstanceVo to register from config
+func (t *EurekaInstanceConfig) InstanceVo() (*InstanceVo, error) {
+    err := t.Validate()
+    if err != nil {
+        return nil, err
+    }
+
+    hostname := t.GetHostname()
+    vo := &InstanceVo{
+        Hostname:         hostname,
+        App:              t.Appname,
+        AppGroupName:     t.AppGroupName,
+        ASGName:          t.ASGName,
+        IppAddr:          t.IpAddress,
+        VipAddress:       t.VirtualHostName,
+        SecureVipAddress: t.SecureVirtualHostName,
+        Status:           STATUS_STARTING,
+        Port:             positiveInt{Value: t.NonSecurePort, Enabled: strconv.FormatBool(t.NonSecurePortEnabled)},
+        SecurePort:       positiveInt{Value: t.SecurePort, Enabled: strconv.FormatBool(t.SecurePortEnabled)},
+        DataCenterInfo: DataCenterInfo{
+            Class: "com.netflix.appinfo.InstanceInfo$DefaultDataCenterInfo",
+            Name:  DC_NAME_TYPE_MY_OWN,
+        },
+        LeaseInfo: LeaseInfo{
+            RenewalIntervalInSecs:  t.LeaseRenewalIntervalInSeconds,
+            EvictionDurationInSecs: t.LeaseExpirationDurationInSeconds,
+        },
+        InstanceId: t.instanceId(hostname),
+    }
+    if vo.VipAddress == "" {
+        vo.VipAddress = strings.ToLower(t.Appname)
+    }
+    if vo.SecureVipAddress == "" {
+        vo.SecureVipAddress = strings.ToLower(t.Appname)
+    }
+    if len(t.MetadataMap) > 0 {
+        vo.Metadata = mergeMetadata(t.MetadataMap, nil)
+    }
+
+    // https while only secure port enabled
+    baseUrl := fmt.Sprintf("http://%s:%d", hostname, t.NonSecurePort)
+    if !t.NonSecurePortEnabled {
+        baseUrl = fmt.Sprintf("https://%s:%d", hostname, t.SecurePort)
+    }
+    vo.HomePageUrl = getUrl(t.HomePageUrl, baseUrl, t.HomePageUrlPath)
+    vo.StatusPageUrl = getUrl(t.StatusPageUrl, baseUrl, t.StatusPageUrlPath)
+    vo.HealthCheckUrl = getUrl(t.HealthCheckUrl, baseUrl, t.HealthCheckUrlPath)
+    if t.SecurePortEnabled {
+        vo.SecureHealthCheckUrl = getUrl(t.SecureHealthCheckUrl, fmt.Sprintf("https://%s:%d", hostname, t.SecurePort), t.HealthCheckUrlPath)
+    }
+
+    return vo, nil
+}
+
+// instance id by template, e.g: {hostname}:{appname}:{port}
+func (t *EurekaInstanceConfig) instanceId(hostname string) string {
+    template := t.InstanceId
+    if template == "" {
+        template = DEFAULT_INSTANCE_ID_TEMPLATE
+    }
+
+    return strings.NewReplacer(
+        "{hostname}", hostname,
+        "{ipAddress}", t.IpAddress,
+        "{appname}", t.Appname,
+        "{port}", strconv.Itoa(t.NonSecurePort),
+        "{securePort}", strconv.Itoa(t.SecurePort),
+    ).Replace(template)
+}
+
+// full url, or path appended to baseUrl
+func getUrl(url, baseUrl, path string) string {
+    if url != "" {
+        return url
+    }
+
+    return strings.TrimRight(baseUrl, "/") + "/" + strings.TrimLeft(path, "/")
+}
@@ -0,0 +1,59 @@
+package eureka
+
+import (
+    "strings"
+    "testing"
+)
+
+func Test_EurekaInstanceConfig(t *testing.T) {
+    config := GetDefaultEurekaInstanceConfig()
+    config.Appname = "TEST-APP"
+    config.IpAddress = "10.0.0.1"
+    config.Hostname = "test-host"
+    config.NonSecurePort = 9000
+    config.SecurePortEnabled = true
+    config.SecurePort = 9443
+    config.InstanceId = "{appname}-{ipAddress}-{securePort}"
+    config.MetadataMap = map[string]string{"version": "1.0"}
+
+    vo, err := config.InstanceVo()
+    if err != nil {
+        t.Fatal(err.Error())
+    }
+    if vo.InstanceId != "TEST-APP-10.0.0.1-9443" || vo.Hostname != "test-host" || vo.VipAddress != "test-app" {
+        t.Fatalf("Unexpected instance: %+v", vo)
+    }
+    if vo.HomePageUrl != "http://test-host:9000/" || vo.HealthCheckUrl != "http://test-host:9000/health" || vo.SecureHealthCheckUrl != "https://test-host:9443/health" {
+        t.Fatalf("Unexpected urls: %s, %s, %s", vo.HomePageUrl, vo.HealthCheckUrl, vo.SecureHealthCheckUrl)
+    }
+    if vo.Port.Enabled != "true" || vo.SecurePort.Enabled != "true" || vo.Metadata["version"] != "1.0" {
+        t.Fatalf("Unexpected instance: %+v", vo)
+    }
+
+    // prefer ip address
+    config.PreferIpAddress = true
+    config.InstanceId = ""
+    vo, err = config.InstanceVo()
+    if err != nil {
+        t.Fatal(err.Error())
+    }
+    if vo.Hostname != "10.0.0.1" || vo.InstanceId != "10.0.0.1:TEST-APP:9000" {
+        t.Fatalf("Unexpected instance: %+v", vo)
+    }
+}
+
+func Test_EurekaInstanceConfigValidate(t *testing.T) {
+    config := GetDefaultEurekaInstanceConfig()
+    config.NonSecurePort = 0
+    config.LeaseExpirationDurationInSeconds = 10
+
+    err := config.Validate()
+    if err == nil {
+        t.Fatal("Invalid instance config should fail")
+    }
+    for _, field := range []string{"Appname", "NonSecurePort", "LeaseExpirationDurationInSeconds"} {
+        if !strings.Contains(err.Error(), field) {
+            t.Fatalf("Expected %s reported, got %s", field, err.Error())
+        }
+    }
+}
@@ -0,0 +1,484 @@
+// Package eurekatest provides an in-process, Eureka-compatible server for testing
+// code built on eureka.Client and eureka.EurekaServerApi hermetically.
+//
+// e.g:
+//     server := eurekatest.NewServer()
+//     defer server.Close()
+//
+//     config := eureka.GetDefaultEurekaClientConfig()
+//     config.ServiceUrl = map[string]string{eureka.DEFAULT_ZONE: server.ServiceUrl()}
+package eurekatest
+
+import (
+    "compress/gzip"
+    "fmt"
+    "io/ioutil"
+    "net/http"
+    "net/http/httptest"
+    "sort"
+    "strconv"
+    "strings"
+    "sync"
+    "time"
+
+    "github.com/HikoQiu/go-eureka-client/eureka"
+)
+
+const (
+    // url context of service url, e.g: http://127.0.0.1:51234/eureka
+    DEFAULT_URL_CONTEXT = "/eureka"
+
+    // lease duration while instance doesn't specify one
+    DEFAULT_LEASE_DURATION = 90 * time.Second
+
+    // how long changes are kept for delta queries (/apps/delta)
+    DEFAULT_DELTA_RETENTION = 3 * time.Minute
+)
+
+// fake eureka server, keeps registry in memory
+type Server struct {
+    *httptest.Server
+
+    // lease duration while instance doesn't specify LeaseInfo.EvictionDurationInSecs
+    LeaseDuration time.Duration
+
+    // how long changes are kept for delta queries
+    DeltaRetention time.Duration
+
+    // clock of server, replace it to simulate lease expiry
+    Now func() time.Time
+
+    // key: app name
+    // value: leases of app, key: instanceId
+    apps map[string]map[string]*lease
+
+    // recently changed instances, for delta queries
+    changes []change
+
+    version int
+    mu      sync.Mutex
+}
+
+type lease struct {
+    instance    eureka.InstanceVo
+    lastRenewal time.Time
+}
+
+type change struct {
+    instance eureka.InstanceVo
+    at       time.Time
+}
+
+// start a new fake eureka server, call Close() when finished
+func NewServer() *Server {
+    s := newServer()
+    s.Server = httptest.NewServer(http.StripPrefix(DEFAULT_URL_CONTEXT, http.HandlerFunc(s.handle)))
+
+    return s
+}
+
+// start a new fake eureka server serving https, call Close() when finished.
+// use Client() (of httptest.Server) as http client, which trusts the server certificate
+func NewTLSServer() *Server {
+    s := newServer()
+    s.Server = httptest.NewTLSServer(http.StripPrefix(DEFAULT_URL_CONTEXT, http.HandlerFunc(s.handle)))
+
+    return s
+}
+
+func newServer() *Server {
+    return &Server{
+        LeaseDuration:  DEFAULT_LEASE_DURATION,
+        DeltaRetention: DEFAULT_DELTA_RETENTION,
+        Now:            time.Now,
+        apps:           map[string]map[string]*lease{},
+        changes:        []change{},
+    }
+}
+
+// service url to configure eureka client with
+func (s *Server) ServiceUrl() string {
+    return s.URL + DEFAULT_URL_CONTEXT
+}
+
+// instances of app currently registered
+func (s *Server) Instances(appId string) []eureka.InstanceVo {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    s.evictExpired()
+    return s.appInstances(strings.ToUpper(appId))
+}
+
+// evict instance as if its lease expired, return false while instance not found
+func (s *Server) Evict(appId, instanceId string) bool {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    return s.cancel(strings.ToUpper(appId), instanceId)
+}
+
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    s.evictExpired()
+
+    paths := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+    switch {
+    case len(paths) == 1 && paths[0] == "apps" && r.Method == http.MethodGet:
+        s.writeApplications(w, r, s.allApps(), s.hashCode())
+    case len(paths) == 2 && paths[0] == "apps" && paths[1] == "delta" && r.Method == http.MethodGet:
+        s.writeApplications(w, r, s.deltaApps(), s.hashCode())
+    case len(paths) == 2 && paths[0] == "apps" && r.Method == http.MethodPost:
+        s.register(w, r, strings.ToUpper(paths[1]))
+    case len(paths) == 2 && paths[0] == "apps" && r.Method == http.MethodGet:
+        s.getApp(w, r, strings.ToUpper(paths[1]))
+    case len(paths) == 3 && paths[0] == "apps":
+        s.handleInstance(w, r, strings.ToUpper(paths[1]), paths[2])
+    case len(paths) == 4 && paths[0] == "apps" && paths[3] == "status":
+        s.updateStatus(w, r, strings.ToUpper(paths[1]), paths[2])
+    case len(paths) == 4 && paths[0] == "apps" && paths[3] == "metadata" && r.Method == http.MethodPut:
+        s.updateMetadata(w, r, strings.ToUpper(paths[1]), paths[2])
+    case len(paths) == 2 && paths[0] == "instances" && r.Method == http.MethodGet:
+        s.getInstance(w, r, paths[1])
+    case len(paths) == 2 && paths[0] == "vips" && r.Method == http.MethodGet:
+        s.writeApplications(w, r, s.vipApps(paths[1], false), s.hashCode())
+    case len(paths) == 2 && paths[0] == "svips" && r.Method == http.MethodGet:
+        s.writeApplications(w, r, s.vipApps(paths[1], true), s.hashCode())
+    default:
+        w.WriteHeader(http.StatusNotFound)
+    }
+}
+
+func (s *Server) handleInstance(w http.ResponseWriter, r *http.Request, app, instanceId string) {
+    switch r.Method {
+    case http.MethodGet:
+        l := s.lookup(app, instanceId)
+        if l == nil {
+            w.WriteHeader(http.StatusNotFound)
+            return
+        }
+        s.write(w, r, func(codec eureka.Codec) ([]byte, error) {
+            return codec.EncodeInstance(&l.instance)
+        })
+    case http.MethodPut:
+        // heartbeat
+        l := s.lookup(app, instanceId)
+        if l == nil {
+            w.WriteHeader(http.StatusNotFound)
+            return
+        }
+        l.lastRenewal = s.Now()
+        l.instance.LeaseInfo.LastRenewalTimestamp = timestamp(l.lastRenewal)
+        w.WriteHeader(http.StatusOK)
+    case http.MethodDelete:
+        if !s.cancel(app, instanceId) {
+            w.WriteHeader(http.StatusNotFound)
+            return
+        }
+        w.WriteHeader(http.StatusOK)
+    default:
+        w.WriteHeader(http.StatusMethodNotAllowed)
+    }
+}
+
+func (s *Server) register(w http.ResponseWriter, r *http.Request, app string) {
+    body, err := ioutil.ReadAll(r.Body)
+    if err != nil {
+        http.Error(w, err.Error(), http.StatusBadRequest)
+        return
+    }
+
+    vo, err := eureka.GetCodecByContentType(r.Header.Get("Content-Type")).DecodeInstance(body)
+    if err != nil {
+        http.Error(w, err.Error(), http.StatusBadRequest)
+        return
+    }
+    if vo == nil || vo.InstanceId == "" {
+        http.Error(w, "instance (with instanceId) is required", http.StatusBadRequest)
+        return
+    }
+    ins := *vo
+    ins.App = app
+
+    if _, ok := s.apps[app]; !ok {
+        s.apps[app] = map[string]*lease{}
+    }
+    now := s.Now()
+    ins.LeaseInfo.RegistrationTimestamp = timestamp(now)
+    ins.LeaseInfo.LastRenewalTimestamp = timestamp(now)
+    s.apps[app][ins.InstanceId] = &lease{instance: ins, lastRenewal: now}
+    s.recordChange(s.apps[app][ins.InstanceId], eureka.ACTION_TYPE_ADDED)
+
+    w.WriteHeader(http.StatusNoContent)
+}
+
+// PUT: override status with value, DELETE: remove override status
+func (s *Server) updateStatus(w http.ResponseWriter, r *http.Request, app, instanceId string) {
+    l := s.lookup(app, instanceId)
+    if l == nil {
+        w.WriteHeader(http.StatusNotFound)
+        return
+    }
+
+    status := r.URL.Query().Get("value")
+    switch r.Method {
+    case http.MethodPut:
+        if status == "" {
+            http.Error(w, "status value is required", http.StatusBadRequest)
+            return
+        }
+        l.instance.Status = status
+        l.instance.OverriddenStatus = status
+    case http.MethodDelete:
+        if status == "" {
+            status = eureka.STATUS_UNKNOWN
+        }
+        l.instance.Status = status
+        l.instance.OverriddenStatus = eureka.STATUS_UNKNOWN
+    default:
+        w.WriteHeader(http.StatusMethodNotAllowed)
+        return
+    }
+
+    s.recordChange(l, eureka.ACTION_TYPE_MODIFIED)
+    w.WriteHeader(http.StatusOK)
+}
+
+func (s *Server) updateMetadata(w http.ResponseWriter, r *http.Request, app, instanceId string) {
+    l := s.lookup(app, instanceId)
+    if l == nil {
+        w.WriteHeader(http.StatusNotFound)
+        return
+    }
+
+    metadata := map[string]string{}
+    for k, v := range l.instance.Metadata {
+        metadata[k] = v
+    }
+    for k := range r.URL.Query() {
+        metadata[k] = r.URL.Query().Get(k)
+    }
+    l.instance.Metadata = metadata
+
+    s.recordChange(l, eureka.ACTION_TYPE_MODIFIED)
+    w.WriteHeader(http.StatusOK)
+}
+
+func (s *Server) getApp(w http.ResponseWriter, r *http.Request, app string) {
+    instances := s.appInstances(app)
+    if len(instances) == 0 {
+        w.WriteHeader(http.StatusNotFound)
+        return
+    }
+
+    s.write(w, r, func(codec eureka.Codec) ([]byte, error) {
+        return codec.EncodeApplication(&eureka.ApplicationVo{Name: app, Instances: instances})
+    })
+}
+
+func (s *Server) getInstance(w http.ResponseWriter, r *http.Request, instanceId string) {
+    for app := range s.apps {
+        if l := s.lookup(app, instanceId); l != nil {
+            s.write(w, r, func(codec eureka.Codec) ([]byte, error) {
+                return codec.EncodeInstance(&l.instance)
+            })
+            return
+        }
+    }
+
+    w.WriteHeader(http.StatusNotFound)
+}
+
+func (s *Server) writeApplications(w http.ResponseWriter, r *http.Request, apps []eureka.ApplicationVo, hashCode string) {
+    s.write(w, r, func(codec eureka.Codec) ([]byte, error) {
+        return codec.EncodeApplications(&eureka.ApplicationsVo{
+            VersionDelta: strconv.Itoa(s.version),
+            AppsHashCode: hashCode,
+            Application:  apps,
+        })
+    })
+}
+
+// write response encoded by codec negotiated with Accept header (default json),
+// gzip compressed while Accept-Encoding allows
+func (s *Server) write(w http.ResponseWriter, r *http.Request, encode func(codec eureka.Codec) ([]byte, error)) {
+    codec := eureka.GetCodecByContentType(r.Header.Get("Accept"))
+    body, err := encode(codec)
+    if err != nil {
+        http.Error(w, err.Error(), http.StatusInternalServerError)
+        return
+    }
+
+    w.Header().Set("Content-Type", codec.ContentType())
+    if !strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+        w.Write(body)
+        return
+    }
+
+    w.Header().Set("Content-Encoding", "gzip")
+    gz := gzip.NewWriter(w)
+    gz.Write(body)
+    gz.Close()
+}
+
+func (s *Server) lookup(app, instanceId string) *lease {
+    if leases, ok := s.apps[app]; ok {
+        return leases[instanceId]
+    }
+
+    return nil
+}
+
+func (s *Server) cancel(app, instanceId string) bool {
+    l := s.lookup(app, instanceId)
+    if l == nil {
+        return false
+    }
+
+    delete(s.apps[app], instanceId)
+    if len(s.apps[app]) == 0 {
+        delete(s.apps, app)
+    }
+    s.recordChange(l, eureka.ACTION_TYPE_DELETED)
+
+    return true
+}
+
+// evict instances whose lease expired, and forget changes out of retention
+func (s *Server) evictExpired() {
+    now := s.Now()
+    for app, leases := range s.apps {
+        for instanceId, l := range leases {
+            duration := s.LeaseDuration
+            if l.instance.LeaseInfo.EvictionDurationInSecs > 0 {
+                duration = time.Duration(l.instance.LeaseInfo.EvictionDurationInSecs) * time.Second
+            }
+
+            if now.Sub(l.lastRenewal) > duration {
+                s.cancel(app, instanceId)
+            }
+        }
+    }
+
+    for len(s.changes) > 0 && now.Sub(s.changes[0].at) > s.DeltaRetention {
+        s.changes = s.changes[1:]
+    }
+}
+
+// timestamp in milliseconds
+func timestamp(t time.Time) eureka.Timestamp {
+    return eureka.Timestamp(t.UnixNano() / int64(time.Millisecond))
+}
+
+func (s *Server) recordChange(l *lease, actionType string) {
+    now := s.Now()
+    l.instance.ActionType = actionType
+    l.instance.LastUpdatedTimestamp = timestamp(now)
+
+    s.version++
+    s.changes = append(s.changes, change{instance: l.instance, at: now})
+}
+
+func (s *Server) appInstances(app string) []eureka.InstanceVo {
+    instances := make([]eureka.InstanceVo, 0, len(s.apps[app]))
+    for _, l := range s.apps[app] {
+        instances = append(instances, l.instance)
+    }
+    sort.Slice(instances, func(i, j int) bool {
+        return instances[i].InstanceId < instances[j].InstanceId
+    })
+
+    return instances
+}
+
+func (s *Server) allApps() []eureka.ApplicationVo {
+    apps := make([]eureka.ApplicationVo, 0, len(s.apps))
+    for app := range s.apps {
+        apps = append(apps, eureka.ApplicationVo{Name: app, Instances: s.appInstances(app)})
+    }
+    sort.Slice(apps, func(i, j int) bool {
+        return apps[i].Name < apps[j].Name
+    })
+
+    return apps
+}
+
+// latest change of each instance changed within retention
+func (s *Server) deltaApps() []eureka.ApplicationVo {
+    latest := map[string]map[string]eureka.InstanceVo{}
+    for _, c := range s.changes {
+        if _, ok := latest[c.instance.App]; !ok {
+            latest[c.instance.App] = map[string]eureka.InstanceVo{}
+        }
+        latest[c.instance.App][c.instance.InstanceId] = c.instance
+    }
+
+    apps := make([]eureka.ApplicationVo, 0, len(latest))
+    for app, instances := range latest {
+        vo := eureka.ApplicationVo{Name: app, Instances: []eureka.InstanceVo{}}
+        for _, ins := range instances {
+            vo.Instances = append(vo.Instances, ins)
+        }
+        apps = append(apps, vo)
+    }
+
+    return apps
+}
+
+func (s *Server) vipApps(vipAddress string, secure bool) []eureka.ApplicationVo {
+    apps := make([]eureka.ApplicationVo, 0)
+    for _, app := range s.allApps() {
+        vo := eureka.ApplicationVo{Name: app.Name, Instances: []eureka.InstanceVo{}}
+        for _, ins := range app.Instances {
+            addresses := ins.VipAddress
+            if secure {
+                addresses = ins.SecureVipAddress
+            }
+            if matchVipAddress(addresses, vipAddress) {
+                vo.Instances = append(vo.Instances, ins)
+            }
+        }
+
+        if len(vo.Instances) > 0 {
+            apps = append(apps, vo)
+        }
+    }
+
+    return apps
+}
+
+// vip addresses are comma separated and case insensitive, e.g: app-a,app-a-v2
+func matchVipAddress(addresses, vipAddress string) bool {
+    for _, address := range strings.Split(addresses, ",") {
+        if strings.EqualFold(strings.TrimSpace(address), vipAddress) {
+            return true
+        }
+    }
+
+    return false
+}
+
+// apps hash code, instance count per status, ordered by status, e.g: DOWN_1_UP_5_
+func (s *Server) hashCode() string {
+    statusCount := map[string]int{}
+    for _, leases := range s.apps {
+        for _, l := range leases {
+            statusCount[l.instance.Status]++
+        }
+    }
+
+    statuses := make([]string, 0, len(statusCount))
+    for status := range statusCount {
+        statuses = append(statuses, status)
+    }
+    sort.Strings(statuses)
+
+    hashCode := ""
+    for _, status := range statuses {
+        hashCode += fmt.Sprintf("%s_%d_", status, statusCount[status])
+    }
+
+    return hashCode
+}
@@ -0,0 +1,52 @@
+package eurekatest
+
+import (
+    "testing"
+    "time"
+
+    "github.com/HikoQiu/go-eureka-client/eureka"
+)
+
+func Test_LeaseExpiry(t *testing.T) {
+    server := NewServer()
+    defer server.Close()
+
+    now := time.Now()
+    server.Now = func() time.Time {
+        return now
+    }
+
+    api := eureka.NewEurekaServerApi(server.ServiceUrl())
+    vo := eureka.DefaultInstanceVo()
+    vo.App = "test-lease-expiry"
+    vo.LeaseInfo.EvictionDurationInSecs = 30
+    instanceId, err := api.RegisterInstanceWithVo(vo)
+    if err != nil {
+        t.Fatal(err.Error())
+    }
+
+    // renew lease before expiry
+    now = now.Add(20 * time.Second)
+    err = api.SendHeartbeat(vo.App, instanceId)
+    if err != nil {
+        t.Fatal(err.Error())
+    }
+
+    now = now.Add(20 * time.Second)
+    if len(server.Instances(vo.App)) != 1 {
+        t.Fatal("Instance should be alive after renewal")
+    }
+
+    now = now.Add(20 * time.Second)
+    if len(server.Instances(vo.App)) != 0 {
+        t.Fatal("Instance should be evicted after lease expired")
+    }
+
+    delta, err := api.QueryDeltaInstances()
+    if err != nil {
+        t.Fatal(err.Error())
+    }
+    if len(delta.Application) != 1 || delta.Application[0].Instances[0].ActionType != eureka.ACTION_TYPE_DELETED {
+        t.Fatalf("Evicted instance should be in delta as DELETED, got %v", delta.Application)
+    }
+}
@@ -9,4 +9,5 @@ replace (
 require (
 	github.com/miekg/dns v1.0.15
 	gopkg.in/resty.v1 v1.10.2
+	gopkg.in/yaml.v2 v2.4.0
 )
@@ -0,0 +1,173 @@
+package eureka
+
+import (
+    "sync"
+    "time"
+)
+
+const (
+    // status updates allowed in a burst, refer to Netflix's InstanceInfoReplicator
+    DEFAULT_STATUS_UPDATE_BURST_SIZE = 2
+
+    DEFAULT_INSTANCE_INFO_REPLICATION_INTERVAL_SECONDS         = 30
+    DEFAULT_INITIAL_INSTANCE_INFO_REPLICATION_INTERVAL_SECONDS = 40
+)
+
+// health check of current instance, polled every InstanceInfoReplicationIntervalSeconds,
+// returned status (UP, DOWN, OUT_OF_SERVICE...) is pushed to eureka server while changed.
+// Refer to: Netflix's HealthCheckHandler
+type HealthCheckHandler interface {
+    // currentStatus: status of instance in eureka server
+    GetStatus(currentStatus string) string
+}
+
+// func adapter of HealthCheckHandler, e.g:
+// client.HealthCheckHandler(eureka.HealthCheckHandlerFunc(func(currentStatus string) string {
+//     if db.Ping() != nil {
+//         return eureka.STATUS_DOWN
+//     }
+//     return eureka.STATUS_UP
+// }))
+type HealthCheckHandlerFunc func(currentStatus string) string
+
+func (f HealthCheckHandlerFunc) GetStatus(currentStatus string) string {
+    return f(currentStatus)
+}
+
+// whether status is valid to push to eureka server
+func isValidStatus(status string) bool {
+    switch status {
+    case STATUS_UP, STATUS_DOWN, STATUS_STARTING, STATUS_OUT_OF_SERVICE, STATUS_UNKNOWN:
+        return true
+    }
+
+    return false
+}
+
+// token bucket rate limiter, refills one token every interval, up to burstSize tokens
+type rateLimiter struct {
+    burstSize int
+    interval  time.Duration
+
+    tokens     int
+    lastRefill time.Time
+    mu         sync.Mutex
+}
+
+func newRateLimiter(burstSize int, interval time.Duration) *rateLimiter {
+    return &rateLimiter{
+        burstSize:  burstSize,
+        interval:   interval,
+        tokens:     burstSize,
+        lastRefill: time.Now(),
+    }
+}
+
+// take one token, return false while no token left
+func (t *rateLimiter) acquire() bool {
+    t.mu.Lock()
+    defer t.mu.Unlock()
+
+    now := time.Now()
+    if t.interval > 0 {
+        refill := int(now.Sub(t.lastRefill) / t.interval)
+        if refill > 0 {
+            t.tokens += refill
+            t.lastRefill = t.lastRefill.Add(time.Duration(refill) * t.interval)
+        }
+    }
+    if t.tokens >= t.burstSize {
+        t.tokens = t.burstSize
+        t.lastRefill = now
+    }
+
+    if t.tokens <= 0 {
+        return false
+    }
+    t.tokens--
+    return true
+}
+
+// status of current instance by health check handler,
+// defaultStatus while no handler set or handler returns invalid status
+func (t *Client) healthStatus(defaultStatus string) string {
+    t.mu.RLock()
+    handler := t.healthCheckHandler
+    t.mu.RUnlock()
+    if handler == nil {
+        return defaultStatus
+    }
+
+    status := handler.GetStatus(defaultStatus)
+    if !isValidStatus(status) {
+        log.Errorf("Invalid status=%s from health check handler, use %s instead", status, defaultStatus)
+        return defaultStatus
+    }
+
+    return status
+}
+
+// poll health check handler periodically and push changed status to eureka server,
+// status updates are rate limited (refer to DEFAULT_STATUS_UPDATE_BURST_SIZE)
+func (t *Client) healthCheck() {
+    t.mu.RLock()
+    handler := t.healthCheckHandler
+    t.mu.RUnlock()
+    if handler == nil {
+        return
+    }
+
+    // intervals of 0 (e.g: config built as struct literal) would poll without delay
+    interval := time.Second * time.Duration(positiveOrDefault(t.config.InstanceInfoReplicationIntervalSeconds, DEFAULT_INSTANCE_INFO_REPLICATION_INTERVAL_SECONDS))
+    initialDelay := time.Second * time.Duration(positiveOrDefault(t.config.InitialInstanceInfoReplicationIntervalSeconds, DEFAULT_INITIAL_INSTANCE_INFO_REPLICATION_INTERVAL_SECONDS))
+    limiter := newRateLimiter(DEFAULT_STATUS_UPDATE_BURST_SIZE, interval)
+
+    t.goLoop(func() {
+        if !t.sleep(initialDelay) {
+            return
+        }
+
+        for {
+            t.mu.RLock()
+            current := t.instance.Status
+            t.mu.RUnlock()
+
+            status := t.healthStatus(current)
+            if status != current {
+                if limiter.acquire() {
+                    t.updateStatus(status)
+                } else {
+                    log.Infof("Status update is rate limited, status=%s", status)
+                }
+            }
+
+            if !t.sleep(interval) {
+                return
+            }
+        }
+    })
+}
+
+// push status of current instance to eureka server
+func (t *Client) updateStatus(status string) error {
+    api, err := t.RetryableApi()
+    if err != nil {
+        log.Errorf("Failed to update status=%s, err=%s", status, err.Error())
+        return err
+    }
+
+    err = api.DoContext(t.context(), func(api *EurekaServerApi) error {
+        return api.UpdateInstanceStatusContext(t.ctx, t.instance.App, t.instance.InstanceId, status)
+    })
+    if err != nil {
+        log.Errorf("Failed to update status=%s, err=%s", status, err.Error())
+        return err
+    }
+
+    t.mu.Lock()
+    t.instance.Status = status
+    t.mu.Unlock()
+
+    log.Infof("Update status of %s to %s", t.instance.InstanceId, status)
+    return nil
+}
@@ -0,0 +1,169 @@
+package eureka
+
+import (
+    "math/rand"
+    "strconv"
+    "sync"
+    "time"
+)
+
+const (
+    // metadata key of instance weight, used by WeightedLoadBalancer
+    DEFAULT_WEIGHT_METADATA_KEY = "weight"
+    // metadata key of instance zone, used by ZoneAffinityLoadBalancer
+    DEFAULT_ZONE_METADATA_KEY = "zone"
+)
+
+// strategy to choose one instance from app's instances
+// instances passed in is never empty, nil returned is reported as ErrNoInstance
+type LoadBalancer interface {
+    Choose(appId string, instances []InstanceVo) *InstanceVo
+}
+
+// choose instances one by one (per app)
+type RoundRobinLoadBalancer struct {
+    counters map[string]uint64
+    mu       sync.Mutex
+}
+
+func NewRoundRobinLoadBalancer() *RoundRobinLoadBalancer {
+    return &RoundRobinLoadBalancer{
+        counters: map[string]uint64{},
+    }
+}
+
+func (t *RoundRobinLoadBalancer) Choose(appId string, instances []InstanceVo) *InstanceVo {
+    t.mu.Lock()
+    index := t.counters[appId] % uint64(len(instances))
+    t.counters[appId]++
+    t.mu.Unlock()
+
+    return &instances[index]
+}
+
+// choose instance randomly
+type RandomLoadBalancer struct {
+}
+
+func NewRandomLoadBalancer() *RandomLoadBalancer {
+    return &RandomLoadBalancer{}
+}
+
+func (t *RandomLoadBalancer) Choose(appId string, instances []InstanceVo) *InstanceVo {
+    return &instances[rand.Intn(len(instances))]
+}
+
+// choose instance randomly by weight, weight is read from instance metadata,
+// e.g: metadata weight=10, instances without (valid) weight are weighted as 1
+type WeightedLoadBalancer struct {
+    MetadataKey string
+}
+
+func NewWeightedLoadBalancer() *WeightedLoadBalancer {
+    return &WeightedLoadBalancer{
+        MetadataKey: DEFAULT_WEIGHT_METADATA_KEY,
+    }
+}
+
+func (t *WeightedLoadBalancer) Choose(appId string, instances []InstanceVo) *InstanceVo {
+    weights := make([]int, len(instances))
+    total := 0
+    for i, ins := range instances {
+        weight, err := strconv.Atoi(ins.Metadata[t.MetadataKey])
+        if err != nil || weight < 0 {
+            weight = 1
+        }
+        weights[i] = weight
+        total += weight
+    }
+
+    // all instances weighted as 0
+    if total == 0 {
+        return &instances[rand.Intn(len(instances))]
+    }
+
+    r := rand.Intn(total)
+    for i, weight := range weights {
+        if r < weight {
+            return &instances[i]
+        }
+        r -= weight
+    }
+
+    return &instances[len(instances)-1]
+}
+
+// choose the instance which was chosen least recently (per app),
+// instances not passed in any more (e.g: left registry) are forgotten
+type LeastRecentlyChosenLoadBalancer struct {
+    // key: appId
+    // value: last chosen time, key: instanceId
+    chosenAt map[string]map[string]time.Time
+    mu       sync.Mutex
+}
+
+func NewLeastRecentlyChosenLoadBalancer() *LeastRecentlyChosenLoadBalancer {
+    return &LeastRecentlyChosenLoadBalancer{
+        chosenAt: map[string]map[string]time.Time{},
+    }
+}
+
+func (t *LeastRecentlyChosenLoadBalancer) Choose(appId string, instances []InstanceVo) *InstanceVo {
+    t.mu.Lock()
+    defer t.mu.Unlock()
+
+    // prune instances not passed in
+    chosenAt := make(map[string]time.Time, len(instances))
+    for _, ins := range instances {
+        if at, ok := t.chosenAt[appId][ins.InstanceId]; ok {
+            chosenAt[ins.InstanceId] = at
+        }
+    }
+    t.chosenAt[appId] = chosenAt
+
+    index := 0
+    for i := range instances {
+        // never chosen instance first
+        if _, ok := chosenAt[instances[i].InstanceId]; !ok {
+            index = i
+            break
+        }
+        if chosenAt[instances[i].InstanceId].Before(chosenAt[instances[index].InstanceId]) {
+            index = i
+        }
+    }
+
+    chosenAt[instances[index].InstanceId] = time.Now()
+    return &instances[index]
+}
+
+// prefer instances in the same zone (read from instance metadata, e.g: zone=zone-cn-hz-1),
+// fall back to all instances while no instance in the zone,
+// then choose one by Next load balancer
+type ZoneAffinityLoadBalancer struct {
+    Zone        string
+    MetadataKey string
+    Next        LoadBalancer
+}
+
+func NewZoneAffinityLoadBalancer(zone string, next LoadBalancer) *ZoneAffinityLoadBalancer {
+    return &ZoneAffinityLoadBalancer{
+        Zone:        zone,
+        MetadataKey: DEFAULT_ZONE_METADATA_KEY,
+        Next:        next,
+    }
+}
+
+func (t *ZoneAffinityLoadBalancer) Choose(appId string, instances []InstanceVo) *InstanceVo {
+    sameZone := make([]InstanceVo, 0, len(instances))
+    for _, ins := range instances {
+        if ins.Metadata[t.MetadataKey] == t.Zone {
+            sameZone = append(sameZone, ins)
+        }
+    }
+
+    if len(sameZone) == 0 {
+        return t.Next.Choose(appId, instances)
+    }
+    return t.Next.Choose(appId, sameZone)
+}
@@ -0,0 +1,120 @@
+package eureka
+
+import (
+    "errors"
+    "testing"
+)
+
+func getTestInstances() []InstanceVo {
+    return []InstanceVo{
+        {InstanceId: "ins-1", IppAddr: "10.0.0.1", Port: positiveInt{Value: 8080, Enabled: "true"},
+            Metadata: map[string]string{"zone": "zone-1", "weight": "0"}},
+        {InstanceId: "ins-2", IppAddr: "10.0.0.2", Port: positiveInt{Value: 8080, Enabled: "true"},
+            Metadata: map[string]string{"zone": "zone-2", "weight": "3"}},
+        {InstanceId: "ins-3", IppAddr: "10.0.0.3", Port: positiveInt{Value: 8080, Enabled: "true"},
+            SecurePort: positiveInt{Value: 8443, Enabled: "true"}, Metadata: map[string]string{"zone": "zone-2"}},
+    }
+}
+
+func Test_RoundRobinLoadBalancer(t *testing.T) {
+    lb := NewRoundRobinLoadBalancer()
+    instances := getTestInstances()
+    for i := 0; i < 6; i++ {
+        ins := lb.Choose("APP", instances)
+        if ins.InstanceId != instances[i%3].InstanceId {
+            t.Fatalf("Round %d, expected %s, got %s", i, instances[i%3].InstanceId, ins.InstanceId)
+        }
+    }
+}
+
+func Test_WeightedLoadBalancer(t *testing.T) {
+    lb := NewWeightedLoadBalancer()
+    instances := getTestInstances()
+    for i := 0; i < 100; i++ {
+        if ins := lb.Choose("APP", instances); ins.InstanceId == "ins-1" {
+            t.Fatal("Instance weighted as 0 should never be chosen")
+        }
+    }
+}
+
+func Test_LeastRecentlyChosenLoadBalancer(t *testing.T) {
+    lb := NewLeastRecentlyChosenLoadBalancer()
+    instances := getTestInstances()
+    chosen := map[string]bool{}
+    for i := 0; i < 3; i++ {
+        chosen[lb.Choose("APP", instances).InstanceId] = true
+    }
+    if len(chosen) != 3 {
+        t.Fatalf("Every instance should be chosen once, chosen=%v", chosen)
+    }
+
+    // instances left are forgotten, other apps are kept
+    lb.Choose("OTHER-APP", instances)
+    lb.Choose("APP", instances[:1])
+    if len(lb.chosenAt["APP"]) != 1 || len(lb.chosenAt["OTHER-APP"]) != 1 {
+        t.Fatalf("Unexpected instances remembered: %v", lb.chosenAt)
+    }
+}
+
+func Test_ZoneAffinityLoadBalancer(t *testing.T) {
+    lb := NewZoneAffinityLoadBalancer("zone-2", NewRoundRobinLoadBalancer())
+    instances := getTestInstances()
+    for i := 0; i < 4; i++ {
+        if ins := lb.Choose("APP", instances); ins.Metadata["zone"] != "zone-2" {
+            t.Fatalf("Instance of zone-2 should be chosen, got %s", ins.InstanceId)
+        }
+    }
+
+    lb.Zone = "zone-not-exist"
+    if ins := lb.Choose("APP", instances); ins == nil {
+        t.Fatal("Should fall back to all instances")
+    }
+}
+
+func Test_InstanceBaseUrl(t *testing.T) {
+    instances := getTestInstances()
+    if url := instances[0].BaseUrl(); url != "http://10.0.0.1:8080" {
+        t.Fatalf("Unexpected base url: %s", url)
+    }
+    if url := instances[2].BaseUrl(); url != "https://10.0.0.3:8443" {
+        t.Fatalf("Unexpected base url: %s", url)
+    }
+}
+
+func Test_ChooseInstance(t *testing.T) {
+    config := GetDefaultEurekaClientConfig()
+    client := new(Client).Config(config)
+    instances := getTestInstances()
+    for i := range instances {
+        instances[i].Status = STATUS_UP
+    }
+    instances[1].Status = STATUS_DOWN
+    client.registryApps = map[string]ApplicationVo{
+        "APP": {Name: "APP", Instances: instances},
+    }
+
+    for i := 0; i < 4; i++ {
+        ins, err := client.ChooseInstance("app")
+        if err != nil {
+            t.Fatal(err.Error())
+        }
+        if ins.Status != STATUS_UP {
+            t.Fatalf("Only UP instance should be chosen, got %s", ins.InstanceId)
+        }
+    }
+
+    if _, err := client.ChooseInstance("app-not-exist"); err == nil {
+        t.Fatal("Should fail to choose instance of app not exist")
+    }
+    // load balancer choosing nothing
+    client.LoadBalancer(nilLoadBalancer{})
+    if _, err := client.ChooseInstance("app"); !errors.Is(err, ErrNoInstance) {
+        t.Fatalf("Expected ErrNoInstance, got %v", err)
+    }
+}
+
+type nilLoadBalancer struct{}
+
+func (nilLoadBalancer) Choose(appId string, instances []InstanceVo) *InstanceVo {
+    return nil
+}
@@ -0,0 +1,302 @@
+package eureka
+
+import (
+    "fmt"
+    "net/http"
+    "sort"
+    "strconv"
+    "strings"
+    "sync"
+)
+
+// metrics reported by Client and EurekaServerApi
+const (
+    // counter, labels: result
+    METRIC_REGISTER_TOTAL = "eureka_client_register_total"
+    // counter, labels: result
+    METRIC_HEARTBEAT_TOTAL = "eureka_client_heartbeat_total"
+    // counter, labels: type (full / delta), result
+    METRIC_FETCH_REGISTRY_TOTAL = "eureka_client_fetch_registry_total"
+    // histogram, labels: type (full / delta)
+    METRIC_FETCH_REGISTRY_SECONDS = "eureka_client_fetch_registry_seconds"
+    // gauge, apps in local registry
+    METRIC_REGISTRY_APPS = "eureka_client_registry_apps"
+    // gauge, instances in local registry
+    METRIC_REGISTRY_INSTANCES = "eureka_client_registry_instances"
+    // counter, labels: result
+    METRIC_SERVICE_URL_REFRESH_TOTAL = "eureka_client_service_url_refresh_total"
+    // gauge, eureka server service urls resolved
+    METRIC_SERVICE_URLS = "eureka_client_service_urls"
+    // counter, labels: operation (register, heartbeat, query_apps...), method, status (http status code / error)
+    METRIC_API_REQUESTS_TOTAL = "eureka_client_api_requests_total"
+    // histogram, labels: operation
+    METRIC_API_REQUEST_SECONDS = "eureka_client_api_request_seconds"
+    // counter, labels: operation, encoding (gzip / identity), bytes of response body received (compressed),
+    // reported only if Metrics implements CounterAdder
+    METRIC_API_RESPONSE_BYTES_TOTAL = "eureka_client_api_response_bytes_total"
+    // counter, labels: operation, bytes of response body decoded (decompressed)
+    METRIC_API_RESPONSE_DECODED_BYTES_TOTAL = "eureka_client_api_response_decoded_bytes_total"
+
+    METRIC_RESULT_SUCCESS = "success"
+    METRIC_RESULT_FAILURE = "failure"
+
+    // content type of Prometheus text format
+    PROMETHEUS_CONTENT_TYPE = "text/plain; version=0.0.4; charset=utf-8"
+)
+
+// histogram buckets (seconds) of PrometheusMetrics, same as Prometheus client default
+var DefaultMetricBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+var metricHelps = map[string]string{
+    METRIC_REGISTER_TOTAL:                   "Instance register attempts to eureka server.",
+    METRIC_HEARTBEAT_TOTAL:                  "Heartbeats sent to eureka server.",
+    METRIC_FETCH_REGISTRY_TOTAL:             "Registry fetches from eureka server.",
+    METRIC_FETCH_REGISTRY_SECONDS:           "Latency of registry fetches in seconds.",
+    METRIC_REGISTRY_APPS:                    "Apps in local registry.",
+    METRIC_REGISTRY_INSTANCES:               "Instances in local registry.",
+    METRIC_SERVICE_URL_REFRESH_TOTAL:        "Refreshes of eureka server service urls.",
+    METRIC_SERVICE_URLS:                     "Eureka server service urls resolved.",
+    METRIC_API_REQUESTS_TOTAL:               "Requests sent to eureka server.",
+    METRIC_API_REQUEST_SECONDS:              "Latency of requests to eureka server in seconds.",
+    METRIC_API_RESPONSE_BYTES_TOTAL:         "Bytes of response body received from eureka server.",
+    METRIC_API_RESPONSE_DECODED_BYTES_TOTAL: "Bytes of response body from eureka server after decompression.",
+}
+
+// metrics hook, implement it to report to your metrics system, e.g: Prometheus, StatsD,
+// or use PrometheusMetrics. Refer to Client.Metrics() and WithMetrics().
+// Methods are called concurrently.
+type Metrics interface {
+    // increase counter by 1
+    IncCounter(name string, labels map[string]string)
+
+    // observe value in histogram, e.g: latency in seconds
+    ObserveHistogram(name string, labels map[string]string, value float64)
+
+    // set gauge to value
+    SetGauge(name string, labels map[string]string, value float64)
+}
+
+// optional interface of Metrics, to increase counter by value, e.g: bytes received.
+// METRIC_API_RESPONSE_BYTES_TOTAL and METRIC_API_RESPONSE_DECODED_BYTES_TOTAL are reported
+// only if Metrics implements it, e.g: PrometheusMetrics
+type CounterAdder interface {
+    AddCounter(name string, labels map[string]string, value float64)
+}
+
+// metrics discarded, while no Metrics is set
+type nopMetrics struct{}
+
+func (nopMetrics) IncCounter(name string, labels map[string]string) {}
+
+func (nopMetrics) ObserveHistogram(name string, labels map[string]string, value float64) {}
+
+func (nopMetrics) SetGauge(name string, labels map[string]string, value float64) {}
+
+// metrics result label by err
+func metricResult(err error) string {
+    if err != nil {
+        return METRIC_RESULT_FAILURE
+    }
+    return METRIC_RESULT_SUCCESS
+}
+
+// in-memory Metrics exposed in Prometheus text format, e.g:
+//     metrics := eureka.NewPrometheusMetrics()
+//     client.Metrics(metrics)
+//     http.Handle("/metrics", metrics)
+type PrometheusMetrics struct {
+    buckets []float64
+
+    // key: metric name
+    // value: series, key: formatted labels, e.g: result="success"
+    counters   map[string]map[string]float64
+    gauges     map[string]map[string]float64
+    histograms map[string]map[string]*histogram
+
+    mu sync.Mutex
+}
+
+type histogram struct {
+    // observations per bucket (not cumulative)
+    counts []uint64
+    sum    float64
+    count  uint64
+}
+
+// buckets of histograms, default: DefaultMetricBuckets
+func NewPrometheusMetrics(buckets ...float64) *PrometheusMetrics {
+    if len(buckets) == 0 {
+        buckets = DefaultMetricBuckets
+    }
+    buckets = append([]float64{}, buckets...)
+    sort.Float64s(buckets)
+
+    return &PrometheusMetrics{
+        buckets:    buckets,
+        counters:   map[string]map[string]float64{},
+        gauges:     map[string]map[string]float64{},
+        histograms: map[string]map[string]*histogram{},
+    }
+}
+
+func (t *PrometheusMetrics) IncCounter(name string, labels map[string]string) {
+    t.AddCounter(name, labels, 1)
+}
+
+func (t *PrometheusMetrics) AddCounter(name string, labels map[string]string, value float64) {
+    t.mu.Lock()
+    defer t.mu.Unlock()
+
+    if t.counters[name] == nil {
+        t.counters[name] = map[string]float64{}
+    }
+    t.counters[name][formatLabels(labels)] += value
+}
+
+func (t *PrometheusMetrics) ObserveHistogram(name string, labels map[string]string, value float64) {
+    t.mu.Lock()
+    defer t.mu.Unlock()
+
+    if t.histograms[name] == nil {
+        t.histograms[name] = map[string]*histogram{}
+    }
+    key := formatLabels(labels)
+    h := t.histograms[name][key]
+    if h == nil {
+        h = &histogram{counts: make([]uint64, len(t.buckets))}
+        t.histograms[name][key] = h
+    }
+
+    for i, bound := range t.buckets {
+        if value <= bound {
+            h.counts[i]++
+            break
+        }
+    }
+    h.sum += value
+    h.count++
+}
+
+func (t *PrometheusMetrics) SetGauge(name string, labels map[string]string, value float64) {
+    t.mu.Lock()
+    defer t.mu.Unlock()
+
+    if t.gauges[name] == nil {
+        t.gauges[name] = map[string]float64{}
+    }
+    t.gauges[name][formatLabels(labels)] = value
+}
+
+// expose metrics in Prometheus text format
+func (t *PrometheusMetrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+    w.Header().Set("Content-Type", PROMETHEUS_CONTENT_TYPE)
+    w.Write([]byte(t.String()))
+}
+
+// metrics in Prometheus text format, sorted by metric name and labels
+func (t *PrometheusMetrics) String() string {
+    t.mu.Lock()
+    defer t.mu.Unlock()
+
+    var b strings.Builder
+    for _, name := range sortedKeys(t.counters) {
+        writeMetricHeader(&b, name, "counter")
+        for _, labels := range sortedKeys(t.counters[name]) {
+            writeSample(&b, name, labels, t.counters[name][labels])
+        }
+    }
+
+    for _, name := range sortedKeys(t.gauges) {
+        writeMetricHeader(&b, name, "gauge")
+        for _, labels := range sortedKeys(t.gauges[name]) {
+            writeSample(&b, name, labels, t.gauges[name][labels])
+        }
+    }
+
+    for _, name := range sortedKeys(t.histograms) {
+        writeMetricHeader(&b, name, "histogram")
+        for _, labels := range sortedKeys(t.histograms[name]) {
+            h := t.histograms[name][labels]
+            var cumulative uint64
+            for i, bound := range t.buckets {
+                cumulative += h.counts[i]
+                writeSample(&b, name+"_bucket", joinLabels(labels, `le="`+formatFloat(bound)+`"`), float64(cumulative))
+            }
+            writeSample(&b, name+"_bucket", joinLabels(labels, `le="+Inf"`), float64(h.count))
+            writeSample(&b, name+"_sum", labels, h.sum)
+            writeSample(&b, name+"_count", labels, float64(h.count))
+        }
+    }
+
+    return b.String()
+}
+
+func writeMetricHeader(b *strings.Builder, name, metricType string) {
+    if help, ok := metricHelps[name]; ok {
+        fmt.Fprintf(b, "# HELP %s %s\n", name, help)
+    }
+    fmt.Fprintf(b, "# TYPE %s %s\n", name, metricType)
+}
+
+func writeSample(b *strings.Builder, name, labels string, value float64) {
+    if labels == "" {
+        fmt.Fprintf(b, "%s %s\n", name, formatFloat(value))
+        return
+    }
+    fmt.Fprintf(b, "%s{%s} %s\n", name, labels, formatFloat(value))
+}
+
+// labels sorted by name, e.g: method="GET",status="200"
+func formatLabels(labels map[string]string) string {
+    names := make([]string, 0, len(labels))
+    for name := range labels {
+        names = append(names, name)
+    }
+    sort.Strings(names)
+
+    pairs := make([]string, 0, len(names))
+    for _, name := range names {
+        pairs = append(pairs, name+`="`+escapeLabelValue(labels[name])+`"`)
+    }
+    return strings.Join(pairs, ",")
+}
+
+func joinLabels(labels, label string) string {
+    if labels == "" {
+        return label
+    }
+    return labels + "," + label
+}
+
+// escape '\', '"' and new line in label value
+func escapeLabelValue(value string) string {
+    return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
+
+func formatFloat(value float64) string {
+    return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+func sortedKeys(m interface{}) []string {
+    keys := make([]string, 0)
+    switch v := m.(type) {
+    case map[string]map[string]float64:
+        for k := range v {
+            keys = append(keys, k)
+        }
+    case map[string]map[string]*histogram:
+        for k := range v {
+            keys = append(keys, k)
+        }
+    case map[string]float64:
+        for k := range v {
+            keys = append(keys, k)
+        }
+    case map[string]*histogram:
+        for k := range v {
+            keys = append(keys, k)
+        }
+    }
+    sort.Strings(keys)
+    return keys
+}
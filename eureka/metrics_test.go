@@ -0,0 +1,82 @@
+package eureka
+
+import (
+    "io/ioutil"
+    "net/http"
+    "net/http/httptest"
+    "strings"
+    "testing"
+)
+
+func Test_PrometheusMetrics(t *testing.T) {
+    metrics := NewPrometheusMetrics(0.1, 1)
+    metrics.IncCounter(METRIC_HEARTBEAT_TOTAL, map[string]string{"result": METRIC_RESULT_SUCCESS})
+    metrics.IncCounter(METRIC_HEARTBEAT_TOTAL, map[string]string{"result": METRIC_RESULT_SUCCESS})
+    metrics.SetGauge(METRIC_REGISTRY_APPS, nil, 3)
+    metrics.ObserveHistogram(METRIC_FETCH_REGISTRY_SECONDS, map[string]string{"type": "full"}, 0.05)
+    metrics.ObserveHistogram(METRIC_FETCH_REGISTRY_SECONDS, map[string]string{"type": "full"}, 2)
+    metrics.IncCounter("custom_total", map[string]string{"path": `a"b`})
+
+    rec := httptest.NewRecorder()
+    metrics.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+    if rec.Header().Get("Content-Type") != PROMETHEUS_CONTENT_TYPE {
+        t.Fatalf("Unexpected content type: %s", rec.Header().Get("Content-Type"))
+    }
+
+    body := rec.Body.String()
+    for _, line := range []string{
+        "# TYPE eureka_client_heartbeat_total counter",
+        `eureka_client_heartbeat_total{result="success"} 2`,
+        "# TYPE eureka_client_registry_apps gauge",
+        "eureka_client_registry_apps 3",
+        "# TYPE eureka_client_fetch_registry_seconds histogram",
+        `eureka_client_fetch_registry_seconds_bucket{type="full",le="0.1"} 1`,
+        `eureka_client_fetch_registry_seconds_bucket{type="full",le="1"} 1`,
+        `eureka_client_fetch_registry_seconds_bucket{type="full",le="+Inf"} 2`,
+        `eureka_client_fetch_registry_seconds_sum{type="full"} 2.05`,
+        `eureka_client_fetch_registry_seconds_count{type="full"} 2`,
+        `custom_total{path="a\"b"} 1`,
+    } {
+        if !strings.Contains(body, line+"\n") {
+            t.Fatalf("Expected line %s, got:\n%s", line, body)
+        }
+    }
+}
+
+func Test_ApiOperation(t *testing.T) {
+    cases := map[string]string{
+        "GET /apps":                         "query_apps",
+        "GET /apps/delta":                   "query_delta",
+        "POST /apps/APP":                    "register",
+        "GET /apps/APP":                     "query_app",
+        "PUT /apps/APP/ID":                  "heartbeat",
+        "DELETE /apps/APP/ID":               "deregister",
+        "PUT /apps/APP/ID/status?value=UP":  "update_status",
+        "PUT /apps/APP/ID/metadata?k=v":     "update_metadata",
+        "GET /vips/app":                     "query_vip",
+        "GET /svips/app":                    "query_svip",
+        "GET /instances/ID":                 "query_instance",
+    }
+
+    for req, expected := range cases {
+        parts := strings.SplitN(req, " ", 2)
+        if op := apiOperation(parts[0], parts[1]); op != expected {
+            t.Fatalf("%s: expected %s, got %s", req, expected, op)
+        }
+    }
+}
+
+func Test_MetricsCounterAdder(t *testing.T) {
+    if _, ok := Metrics(NewPrometheusMetrics()).(CounterAdder); !ok {
+        t.Fatal("PrometheusMetrics should implement CounterAdder")
+    }
+
+    // bytes counters are skipped while Metrics doesn't implement CounterAdder
+    body := &countingBody{raw: ioutil.NopCloser(strings.NewReader("{}")), metrics: nopMetrics{}}
+    body.received.r = body.raw
+    data, err := ioutil.ReadAll(body)
+    if err != nil || string(data) != "{}" {
+        t.Fatalf("Unexpected body read: %s, err=%v", data, err)
+    }
+    body.Close()
+}
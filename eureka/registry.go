@@ -0,0 +1,116 @@
+package eureka
+
+import (
+    "fmt"
+    "sort"
+)
+
+// copy registry apps, so that a new snapshot can be modified
+// without affecting the one callers may hold (from GetRegistryApps)
+func copyRegistryApps(apps map[string]ApplicationVo) map[string]ApplicationVo {
+    cp := make(map[string]ApplicationVo, len(apps))
+    for name, app := range apps {
+        instances := make([]InstanceVo, len(app.Instances))
+        copy(instances, app.Instances)
+        cp[name] = ApplicationVo{Name: app.Name, Instances: instances}
+    }
+
+    return cp
+}
+
+// apply delta applications (instances with actionType) onto registry apps
+func applyDelta(apps map[string]ApplicationVo, delta *ApplicationsVo) {
+    for _, deltaApp := range delta.Application {
+        for _, ins := range deltaApp.Instances {
+            app, ok := apps[deltaApp.Name]
+            if !ok {
+                app = ApplicationVo{Name: deltaApp.Name, Instances: []InstanceVo{}}
+            }
+
+            switch ins.ActionType {
+            case ACTION_TYPE_ADDED:
+                fallthrough
+            case ACTION_TYPE_MODIFIED:
+                log.Debugf("Delta %s instance, app=%s, instanceId=%s", ins.ActionType, deltaApp.Name, ins.InstanceId)
+                app.Instances = upsertInstance(app.Instances, ins)
+            case ACTION_TYPE_DELETED:
+                log.Debugf("Delta %s instance, app=%s, instanceId=%s", ins.ActionType, deltaApp.Name, ins.InstanceId)
+                app.Instances = removeInstance(app.Instances, ins.InstanceId)
+            default:
+                log.Errorf("Unknown delta action type=%s, app=%s, instanceId=%s", ins.ActionType, deltaApp.Name, ins.InstanceId)
+                continue
+            }
+
+            if len(app.Instances) == 0 {
+                delete(apps, deltaApp.Name)
+                continue
+            }
+            apps[deltaApp.Name] = app
+        }
+    }
+}
+
+func upsertInstance(instances []InstanceVo, ins InstanceVo) []InstanceVo {
+    for i := range instances {
+        if instances[i].InstanceId == ins.InstanceId {
+            instances[i] = ins
+            return instances
+        }
+    }
+
+    return append(instances, ins)
+}
+
+func removeInstance(instances []InstanceVo, instanceId string) []InstanceVo {
+    for i := range instances {
+        if instances[i].InstanceId == instanceId {
+            return append(instances[:i], instances[i+1:]...)
+        }
+    }
+
+    return instances
+}
+
+// compute apps hash code the way eureka server does, which is
+// instance count per status, ordered by status, e.g: DOWN_1_UP_5_
+func getReconcileHashCode(apps map[string]ApplicationVo) string {
+    statusCount := map[string]int{}
+    for _, app := range apps {
+        for _, ins := range app.Instances {
+            statusCount[ins.Status]++
+        }
+    }
+
+    statuses := make([]string, 0, len(statusCount))
+    for status := range statusCount {
+        statuses = append(statuses, status)
+    }
+    sort.Strings(statuses)
+
+    hashCode := ""
+    for _, status := range statuses {
+        hashCode += fmt.Sprintf("%s_%d_", status, statusCount[status])
+    }
+
+    return hashCode
+}
+
+// log the differences between the local (delta applied) registry and the remote one
+func logRegistryDiff(local, remote map[string]ApplicationVo) {
+    for name, remoteApp := range remote {
+        localApp, ok := local[name]
+        if !ok {
+            log.Infof("Registry diff, app=%s missing locally, remote instances=%d", name, len(remoteApp.Instances))
+            continue
+        }
+        if len(localApp.Instances) != len(remoteApp.Instances) {
+            log.Infof("Registry diff, app=%s, local instances=%d, remote instances=%d", name, len(localApp.Instances), len(remoteApp.Instances))
+        }
+    }
+
+    for name, localApp := range local {
+        if _, ok := remote[name]; !ok {
+            log.Infof("Registry diff, app=%s missing remotely, local instances=%d", name, len(localApp.Instances))
+        }
+    }
+}
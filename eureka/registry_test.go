@@ -0,0 +1,120 @@
+package eureka
+
+import (
+    "testing"
+)
+
+func Test_ApplyDelta(t *testing.T) {
+    apps := map[string]ApplicationVo{
+        "APP-A": {Name: "APP-A", Instances: []InstanceVo{
+            {InstanceId: "a-1", Status: STATUS_UP},
+            {InstanceId: "a-2", Status: STATUS_UP},
+        }},
+        "APP-B": {Name: "APP-B", Instances: []InstanceVo{
+            {InstanceId: "b-1", Status: STATUS_UP},
+        }},
+    }
+    delta := &ApplicationsVo{
+        Application: []ApplicationVo{
+            {Name: "APP-A", Instances: []InstanceVo{
+                {InstanceId: "a-2", Status: STATUS_DOWN, ActionType: ACTION_TYPE_MODIFIED},
+                {InstanceId: "a-3", Status: STATUS_UP, ActionType: ACTION_TYPE_ADDED},
+            }},
+            {Name: "APP-B", Instances: []InstanceVo{
+                {InstanceId: "b-1", ActionType: ACTION_TYPE_DELETED},
+            }},
+            {Name: "APP-C", Instances: []InstanceVo{
+                {InstanceId: "c-1", Status: STATUS_STARTING, ActionType: ACTION_TYPE_ADDED},
+            }},
+        },
+    }
+
+    local := copyRegistryApps(apps)
+    applyDelta(local, delta)
+
+    if _, ok := local["APP-B"]; ok {
+        t.Fatal("APP-B should be removed after its last instance deleted")
+    }
+    if len(local["APP-A"].Instances) != 3 {
+        t.Fatalf("APP-A should have 3 instances, got %d", len(local["APP-A"].Instances))
+    }
+    if len(apps["APP-A"].Instances) != 2 || apps["APP-A"].Instances[1].Status != STATUS_UP {
+        t.Fatal("Original registry apps should not be modified")
+    }
+
+    hashCode := getReconcileHashCode(local)
+    if hashCode != "DOWN_1_STARTING_1_UP_2_" {
+        t.Fatalf("Unexpected hash code: %s", hashCode)
+    }
+    t.Log("Hash code: ", hashCode)
+}
+
+func Test_DiffRegistry(t *testing.T) {
+    prev := map[string]ApplicationVo{
+        "APP-A": {Name: "APP-A", Instances: []InstanceVo{
+            {InstanceId: "a-1", Status: STATUS_UP},
+            {InstanceId: "a-2", Status: STATUS_UP, Metadata: map[string]string{"version": "1"}},
+            {InstanceId: "a-3", Status: STATUS_UP},
+            {InstanceId: "a-4", Status: STATUS_UP},
+        }},
+    }
+    curr := map[string]ApplicationVo{
+        "APP-A": {Name: "APP-A", Instances: []InstanceVo{
+            {InstanceId: "a-1", Status: STATUS_DOWN},
+            {InstanceId: "a-2", Status: STATUS_UP, Metadata: map[string]string{"version": "2"}},
+            // empty metadata equals to nil
+            {InstanceId: "a-4", Status: STATUS_UP, Metadata: map[string]string{}},
+        }},
+        "APP-B": {Name: "APP-B", Instances: []InstanceVo{
+            {InstanceId: "b-1", Status: STATUS_UP},
+        }},
+    }
+
+    events := map[string]string{}
+    for _, event := range diffRegistry(prev, curr) {
+        events[event.Instance.InstanceId] = event.Type
+    }
+
+    expected := map[string]string{
+        "a-1": EVENT_INSTANCE_STATUS_CHANGED,
+        "a-2": EVENT_INSTANCE_METADATA_CHANGED,
+        "a-3": EVENT_INSTANCE_REMOVED,
+        "b-1": EVENT_INSTANCE_ADDED,
+    }
+    if len(events) != len(expected) {
+        t.Fatalf("Expected events %v, got %v", expected, events)
+    }
+    for instanceId, eventType := range expected {
+        if events[instanceId] != eventType {
+            t.Fatalf("Expected %s for %s, got %s", eventType, instanceId, events[instanceId])
+        }
+    }
+}
+
+func Test_Watch(t *testing.T) {
+    client := new(Client).Config(GetDefaultEurekaClientConfig())
+    ch, cancel := client.Watch("app-b")
+
+    listened := 0
+    client.AddRegistryListener(RegistryListenerFunc(func(event RegistryEvent) {
+        listened++
+    }))
+
+    client.updateRegistryApps(map[string]ApplicationVo{
+        "APP-A": {Name: "APP-A", Instances: []InstanceVo{{InstanceId: "a-1", Status: STATUS_UP}}},
+        "APP-B": {Name: "APP-B", Instances: []InstanceVo{{InstanceId: "b-1", Status: STATUS_UP}}},
+    })
+
+    event := <-ch
+    if event.Type != EVENT_INSTANCE_ADDED || event.Instance.InstanceId != "b-1" {
+        t.Fatalf("Unexpected event: %+v", event)
+    }
+    if listened != 2 {
+        t.Fatalf("Listener should receive 2 events, got %d", listened)
+    }
+
+    cancel()
+    if _, ok := <-ch; ok {
+        t.Fatal("Channel should be closed after cancel")
+    }
+}
@@ -0,0 +1,171 @@
+package eureka
+
+import (
+    "strings"
+)
+
+const (
+    EVENT_INSTANCE_ADDED            = "INSTANCE_ADDED"
+    EVENT_INSTANCE_REMOVED          = "INSTANCE_REMOVED"
+    EVENT_INSTANCE_STATUS_CHANGED   = "INSTANCE_STATUS_CHANGED"
+    EVENT_INSTANCE_METADATA_CHANGED = "INSTANCE_METADATA_CHANGED"
+
+    // buffer size of channel returned by Client.Watch()
+    DEFAULT_WATCH_CHAN_SIZE = 64
+)
+
+// registry change event, computed by diffing successive registry snapshots
+type RegistryEvent struct {
+    // INSTANCE_ADDED | INSTANCE_REMOVED | INSTANCE_STATUS_CHANGED | INSTANCE_METADATA_CHANGED
+    Type  string
+    AppId string
+    // current instance (the removed one for INSTANCE_REMOVED)
+    Instance InstanceVo
+    // previous instance, nil for INSTANCE_ADDED
+    PrevInstance *InstanceVo
+}
+
+// listener of registry change events,
+// called in registry refresh goroutine, so it should return quickly
+type RegistryListener interface {
+    OnRegistryEvent(event RegistryEvent)
+}
+
+// adapter to use ordinary function as RegistryListener
+type RegistryListenerFunc func(event RegistryEvent)
+
+func (f RegistryListenerFunc) OnRegistryEvent(event RegistryEvent) {
+    f(event)
+}
+
+type registryWatcher struct {
+    // empty appId watches all apps
+    appId string
+    ch    chan RegistryEvent
+}
+
+// watch registry change events of app (all apps while appId is empty),
+// call the returned cancel function to stop watching and close the channel.
+// events are dropped while channel is full.
+func (t *Client) Watch(appId string) (<-chan RegistryEvent, func()) {
+    w := &registryWatcher{
+        appId: strings.ToUpper(appId),
+        ch:    make(chan RegistryEvent, DEFAULT_WATCH_CHAN_SIZE),
+    }
+
+    t.watchMu.Lock()
+    if t.watchers == nil {
+        t.watchers = map[*registryWatcher]bool{}
+    }
+    t.watchers[w] = true
+    t.watchMu.Unlock()
+
+    cancel := func() {
+        t.watchMu.Lock()
+        defer t.watchMu.Unlock()
+        if _, ok := t.watchers[w]; ok {
+            delete(t.watchers, w)
+            close(w.ch)
+        }
+    }
+
+    return w.ch, cancel
+}
+
+// add listener of registry change events of all apps
+func (t *Client) AddRegistryListener(listener RegistryListener) *Client {
+    t.watchMu.Lock()
+    defer t.watchMu.Unlock()
+
+    t.listeners = append(t.listeners, listener)
+    return t
+}
+
+// publish events to watchers and listeners
+func (t *Client) publishRegistryEvents(events []RegistryEvent) {
+    if len(events) == 0 {
+        return
+    }
+
+    t.watchMu.Lock()
+    for _, event := range events {
+        for w := range t.watchers {
+            if w.appId != "" && w.appId != event.AppId {
+                continue
+            }
+
+            select {
+            case w.ch <- event:
+            default:
+                log.Errorf("Watch channel is full, drop event=%s, app=%s, instanceId=%s", event.Type, event.AppId, event.Instance.InstanceId)
+            }
+        }
+    }
+    listeners := make([]RegistryListener, len(t.listeners))
+    copy(listeners, t.listeners)
+    t.watchMu.Unlock()
+
+    // call listeners without lock, so that listeners are free to watch
+    for _, event := range events {
+        for _, listener := range listeners {
+            listener.OnRegistryEvent(event)
+        }
+    }
+}
+
+// diff successive registry snapshots into events
+func diffRegistry(prev, curr map[string]ApplicationVo) []RegistryEvent {
+    events := make([]RegistryEvent, 0)
+
+    for name, currApp := range curr {
+        prevInstances := map[string]InstanceVo{}
+        for _, ins := range prev[name].Instances {
+            prevInstances[ins.InstanceId] = ins
+        }
+
+        for _, ins := range currApp.Instances {
+            prevIns, ok := prevInstances[ins.InstanceId]
+            if !ok {
+                events = append(events, RegistryEvent{Type: EVENT_INSTANCE_ADDED, AppId: name, Instance: ins})
+                continue
+            }
+
+            if prevIns.Status != ins.Status {
+                events = append(events, RegistryEvent{Type: EVENT_INSTANCE_STATUS_CHANGED, AppId: name, Instance: ins, PrevInstance: &prevIns})
+            }
+            if !equalMetadata(prevIns.Metadata, ins.Metadata) {
+                events = append(events, RegistryEvent{Type: EVENT_INSTANCE_METADATA_CHANGED, AppId: name, Instance: ins, PrevInstance: &prevIns})
+            }
+        }
+    }
+
+    for name, prevApp := range prev {
+        currInstances := map[string]bool{}
+        for _, ins := range curr[name].Instances {
+            currInstances[ins.InstanceId] = true
+        }
+
+        for _, ins := range prevApp.Instances {
+            if !currInstances[ins.InstanceId] {
+                prevIns := ins
+                events = append(events, RegistryEvent{Type: EVENT_INSTANCE_REMOVED, AppId: name, Instance: ins, PrevInstance: &prevIns})
+            }
+        }
+    }
+
+    return events
+}
+
+// nil and empty metadata are equal, either may be decoded from full or delta registry
+func equalMetadata(a, b map[string]string) bool {
+    if len(a) != len(b) {
+        return false
+    }
+    for k, v := range a {
+        if bv, ok := b[k]; !ok || bv != v {
+            return false
+        }
+    }
+
+    return true
+}
@@ -0,0 +1,162 @@
+package eureka
+
+import (
+    "context"
+    "errors"
+    "net/http"
+    "sync"
+    "time"
+)
+
+const (
+    DEFAULT_QUARANTINE_SECONDS = 30
+    DEFAULT_MAX_RETRIES        = 3
+)
+
+// EurekaServerApi wrapper which retries the same request on the next service url,
+// service urls are tried in (zone-aware) order and failing ones are quarantined for a while.
+// Refer to: Netflix's RetryableEurekaHttpClient
+type RetryableEurekaServerApi struct {
+    // how long a failing service url is quarantined
+    QuarantineDuration time.Duration
+
+    // max number of service urls tried for one request
+    MaxRetries int
+
+    // service urls in preferred order, e.g: same zone first
+    serviceUrls []string
+
+    // service url succeeded last time, tried first
+    current string
+
+    // key: service url
+    // value: quarantined until
+    quarantined map[string]time.Time
+
+    opts []ApiOption
+    mu   sync.Mutex
+}
+
+func NewRetryableEurekaServerApi(serviceUrls []string, opts ...ApiOption) *RetryableEurekaServerApi {
+    return &RetryableEurekaServerApi{
+        QuarantineDuration: time.Second * DEFAULT_QUARANTINE_SECONDS,
+        MaxRetries:         DEFAULT_MAX_RETRIES,
+        serviceUrls:        serviceUrls,
+        quarantined:        map[string]time.Time{},
+        opts:               opts,
+    }
+}
+
+// update service urls, e.g: after service urls refreshed from DNS
+func (t *RetryableEurekaServerApi) SetServiceUrls(serviceUrls []string) {
+    t.mu.Lock()
+    defer t.mu.Unlock()
+
+    t.serviceUrls = serviceUrls
+}
+
+// call f with EurekaServerApi of service urls one by one till success,
+// the same request is retried on the next service url while
+// request failed with transport error or 5xx status code
+func (t *RetryableEurekaServerApi) Do(f func(api *EurekaServerApi) error) error {
+    return t.DoContext(context.Background(), f)
+}
+
+// same as Do(), but stops trying other service urls once ctx is done,
+// ctx should be the one passed to requests in f
+func (t *RetryableEurekaServerApi) DoContext(ctx context.Context, f func(api *EurekaServerApi) error) error {
+    candidates, opts := t.candidates()
+    if len(candidates) == 0 {
+        return ErrNoServiceUrl
+    }
+
+    var err error
+    for i, url := range candidates {
+        if t.MaxRetries > 0 && i >= t.MaxRetries {
+            break
+        }
+
+        err = f(NewEurekaServerApi(url, opts...))
+        if err == nil {
+            t.mu.Lock()
+            t.current = url
+            t.mu.Unlock()
+            return nil
+        }
+
+        // canceled or timed out by caller
+        if ctx.Err() != nil {
+            return err
+        }
+
+        // eureka server responded, retrying on other service urls makes no sense
+        if !isRetryableError(err) {
+            return err
+        }
+
+        t.quarantine(url)
+        log.Errorf("Request to %s failed, quarantined for %s, err=%s", url, t.QuarantineDuration, err.Error())
+    }
+
+    return err
+}
+
+// service urls not quarantined, last succeeded one first,
+// all service urls while all of them are quarantined
+func (t *RetryableEurekaServerApi) candidates() ([]string, []ApiOption) {
+    t.mu.Lock()
+    defer t.mu.Unlock()
+
+    now := time.Now()
+    candidates := make([]string, 0, len(t.serviceUrls))
+    for _, url := range t.serviceUrls {
+        if until, ok := t.quarantined[url]; ok && now.Before(until) {
+            continue
+        }
+
+        if url == t.current {
+            candidates = append([]string{url}, candidates...)
+            continue
+        }
+        candidates = append(candidates, url)
+    }
+
+    if len(candidates) == 0 && len(t.serviceUrls) > 0 {
+        log.Infof("All service urls are quarantined, clear quarantine")
+        t.quarantined = map[string]time.Time{}
+        candidates = append(candidates, t.serviceUrls...)
+    }
+
+    return candidates, t.opts
+}
+
+func (t *RetryableEurekaServerApi) quarantine(url string) {
+    t.mu.Lock()
+    defer t.mu.Unlock()
+
+    t.quarantined[url] = time.Now().Add(t.QuarantineDuration)
+    if t.current == url {
+        t.current = ""
+    }
+}
+
+// transport errors (including timeout of single request) and 5xx responses are retryable,
+// but not responses failed to decode or requests canceled by caller
+func isRetryableError(err error) bool {
+    if errors.Is(err, context.Canceled) {
+        return false
+    }
+
+    // eureka server responded, but not decodable
+    var decodeErr *DecodeError
+    if errors.As(err, &decodeErr) {
+        return false
+    }
+
+    var httpErr *HTTPError
+    if errors.As(err, &httpErr) {
+        return httpErr.StatusCode >= http.StatusInternalServerError
+    }
+
+    return true
+}
@@ -0,0 +1,171 @@
+package eureka_test
+
+import (
+    "context"
+    "errors"
+    "net/http"
+    "net/http/httptest"
+    "sync/atomic"
+    "testing"
+    "time"
+
+    "github.com/HikoQiu/go-eureka-client/eureka"
+    "github.com/HikoQiu/go-eureka-client/eureka/eurekatest"
+)
+
+func Test_RetryableApiFailover(t *testing.T) {
+    server := eurekatest.NewServer()
+    defer server.Close()
+
+    requests := 0
+    broken := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        requests++
+        w.WriteHeader(http.StatusServiceUnavailable)
+    }))
+    defer broken.Close()
+
+    api := eureka.NewRetryableEurekaServerApi([]string{broken.URL + "/eureka", server.ServiceUrl()})
+    api.QuarantineDuration = time.Minute
+
+    for i := 0; i < 3; i++ {
+        err := api.Do(func(api *eureka.EurekaServerApi) error {
+            _, err := api.QueryAllInstances()
+            return err
+        })
+        if err != nil {
+            t.Fatal(err.Error())
+        }
+    }
+
+    // broken service url is quarantined after the first failure
+    if requests != 1 {
+        t.Fatalf("Broken service url should be requested once, got %d", requests)
+    }
+}
+
+func Test_RetryableApiNotRetry4xx(t *testing.T) {
+    server := eurekatest.NewServer()
+    defer server.Close()
+    other := eurekatest.NewServer()
+    defer other.Close()
+
+    api := eureka.NewRetryableEurekaServerApi([]string{server.ServiceUrl(), other.ServiceUrl()})
+    err := api.Do(func(api *eureka.EurekaServerApi) error {
+        if api.BaseUrl == other.ServiceUrl() {
+            t.Fatal("Request responded with 4xx should not be retried")
+        }
+        return api.SendHeartbeat("NOT-FOUND", "NOT-FOUND")
+    })
+    if err == nil {
+        t.Fatal("Heartbeat of instance not registered should fail")
+    }
+}
+
+func Test_RetryableApiAllQuarantined(t *testing.T) {
+    api := eureka.NewRetryableEurekaServerApi([]string{"http://127.0.0.1:1/eureka"})
+    for i := 0; i < 2; i++ {
+        tried := false
+        api.Do(func(api *eureka.EurekaServerApi) error {
+            tried = true
+            return api.SendHeartbeat(test_app_name, "instance-id")
+        })
+        if !tried {
+            t.Fatal("Service urls should be tried while all of them are quarantined")
+        }
+    }
+}
+
+func Test_RetryableApiFailoverOnTimeout(t *testing.T) {
+    server := eurekatest.NewServer()
+    defer server.Close()
+
+    var hits int32
+    release := make(chan struct{})
+    hung := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        atomic.AddInt32(&hits, 1)
+        <-release
+    }))
+    defer hung.Close()
+    defer close(release)
+
+    httpClient := &http.Client{Timeout: time.Millisecond * 200}
+    api := eureka.NewRetryableEurekaServerApi([]string{hung.URL + "/eureka", server.ServiceUrl()}, eureka.WithHttpClient(httpClient))
+    api.QuarantineDuration = time.Minute
+
+    for i := 0; i < 2; i++ {
+        err := api.DoContext(context.Background(), func(api *eureka.EurekaServerApi) error {
+            _, err := api.QueryAllInstancesContext(context.Background())
+            return err
+        })
+        if err != nil {
+            t.Fatalf("Request should be retried on the healthy service url, err=%s", err.Error())
+        }
+    }
+
+    // hung service url is quarantined after the first timeout
+    if n := atomic.LoadInt32(&hits); n != 1 {
+        t.Fatalf("Hung service url should be requested once, got %d", n)
+    }
+}
+
+func Test_RetryableApiNotRetryCanceled(t *testing.T) {
+    server := eurekatest.NewServer()
+    defer server.Close()
+    other := eurekatest.NewServer()
+    defer other.Close()
+
+    ctx, cancel := context.WithCancel(context.Background())
+    cancel()
+
+    api := eureka.NewRetryableEurekaServerApi([]string{server.ServiceUrl(), other.ServiceUrl()})
+    tried := 0
+    err := api.DoContext(ctx, func(api *eureka.EurekaServerApi) error {
+        tried++
+        _, err := api.QueryAllInstancesContext(ctx)
+        return err
+    })
+    if err == nil {
+        t.Fatal("Request canceled by caller should fail")
+    }
+    if tried != 1 {
+        t.Fatalf("Request canceled by caller should not be retried, tried %d", tried)
+    }
+}
+
+func Test_RetryableApiNotRetryDecodeError(t *testing.T) {
+    server := eurekatest.NewServer()
+    defer server.Close()
+
+    requests := 0
+    garbled := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        requests++
+        w.Header().Set("Content-Type", "application/json")
+        w.Write([]byte("{not json"))
+    }))
+    defer garbled.Close()
+
+    api := eureka.NewRetryableEurekaServerApi([]string{garbled.URL + "/eureka", server.ServiceUrl()})
+    tried := 0
+    err := api.Do(func(api *eureka.EurekaServerApi) error {
+        tried++
+        _, err := api.QueryAllInstances()
+        return err
+    })
+
+    var decodeErr *eureka.DecodeError
+    if !errors.As(err, &decodeErr) {
+        t.Fatalf("Expected *DecodeError, got %v", err)
+    }
+    if tried != 1 || requests != 1 {
+        t.Fatalf("Response failed to decode should not be retried, tried %d", tried)
+    }
+
+    // not quarantined, requested again
+    api.Do(func(api *eureka.EurekaServerApi) error {
+        _, err := api.QueryAllInstances()
+        return err
+    })
+    if requests != 2 {
+        t.Fatalf("Service url responded should not be quarantined, requested %d", requests)
+    }
+}
@@ -1,8 +1,18 @@
-package eureka
+package eureka_test
 
 import (
+    "context"
     "encoding/json"
+    "errors"
+    "net/http"
+    "net/http/httptest"
+    "strconv"
+    "strings"
     "testing"
+    "time"
+
+    "github.com/HikoQiu/go-eureka-client/eureka"
+    "github.com/HikoQiu/go-eureka-client/eureka/eurekatest"
 )
 
 const (
@@ -10,144 +20,348 @@ const (
     test_instance_port = 9000
 )
 
-func Test_RegisterInstance(t *testing.T) {
-    config := getTestDnsEurekaConfig()
-    endpointUtils := new(EndpointUtils)
-    urls, err := endpointUtils.GetDiscoveryServiceUrls(config, "zone-cn-hz-1")
+// start fake eureka server with test app registered
+func startTestServer(t *testing.T) (*eurekatest.Server, string) {
+    server := eurekatest.NewServer()
+    instanceId, err := eureka.NewEurekaServerApi(server.ServiceUrl()).RegisterInstance(test_app_name, test_instance_port)
     if err != nil {
-        t.Fatal(err.Error())
+        server.Close()
+        t.Fatal("Failed to register app: ", err.Error())
     }
 
-    t.Log("Eureka server url: ", urls[0])
-    instanceId, err := NewEurekaServerApi(urls[0]).RegisterInstance(test_app_name, test_instance_port)
+    return server, instanceId
+}
+
+func Test_RegisterInstance(t *testing.T) {
+    server := eurekatest.NewServer()
+    defer server.Close()
+
+    t.Log("Eureka server url: ", server.ServiceUrl())
+    instanceId, err := eureka.NewEurekaServerApi(server.ServiceUrl()).RegisterInstance(test_app_name, test_instance_port)
     if err != nil {
-        t.Error("Failed to register app: ", err.Error())
+        t.Fatal("Failed to register app: ", err.Error())
     }
 
+    instances := server.Instances(test_app_name)
+    if len(instances) != 1 || instances[0].InstanceId != instanceId {
+        t.Fatalf("Instance %s should be registered, got %v", instanceId, instances)
+    }
     t.Log("Success to register app, instance-id: ", instanceId)
 }
 
 func Test_QueryAllInstances(t *testing.T) {
-    config := getTestDnsEurekaConfig()
-    endpointUtils := new(EndpointUtils)
-    urls, err := endpointUtils.GetDiscoveryServiceUrls(config, "zone-cn-hz-1")
-    if err != nil {
-        t.Fatal(err.Error())
-    }
+    server, _ := startTestServer(t)
+    defer server.Close()
 
-    t.Log("Eureka server url: ", urls[0])
-    applications, err := NewEurekaServerApi(urls[0]).QueryAllInstances()
+    applications, err := eureka.NewEurekaServerApi(server.ServiceUrl()).QueryAllInstances()
     if err != nil {
         t.Fatal("Failed to query all intances: ", err.Error())
     }
+    if len(applications) != 1 || len(applications[0].Instances) != 1 {
+        t.Fatalf("Expected 1 application with 1 instance, got %v", applications)
+    }
     str, _ := json.Marshal(applications)
     t.Log("Success to query all instances, applications: ", string(str))
 }
 
-func Test_SendHeartbeat(t *testing.T) {
-    config := getTestDnsEurekaConfig()
-    endpointUtils := new(EndpointUtils)
-    urls, err := endpointUtils.GetDiscoveryServiceUrls(config, "zone-cn-hz-1")
+func Test_QueryDeltaInstances(t *testing.T) {
+    server, instanceId := startTestServer(t)
+    defer server.Close()
+
+    api := eureka.NewEurekaServerApi(server.ServiceUrl())
+    err := api.DeRegisterInstance(test_app_name, instanceId)
+    if err != nil {
+        t.Fatal(err.Error())
+    }
+
+    delta, err := api.QueryDeltaInstances()
     if err != nil {
         t.Fatal(err.Error())
     }
+    if len(delta.Application) != 1 || delta.Application[0].Instances[0].ActionType != eureka.ACTION_TYPE_DELETED {
+        t.Fatalf("Expected DELETED instance in delta, got %v", delta.Application)
+    }
+    if delta.AppsHashCode != "" {
+        t.Fatalf("Expected empty apps hash code, got %s", delta.AppsHashCode)
+    }
+}
 
-    t.Log("Eureka server url: ", urls[0])
+func Test_SendHeartbeat(t *testing.T) {
+    server, instanceId := startTestServer(t)
+    defer server.Close()
 
     // TEST NOT-FOUND
-    err = NewEurekaServerApi(urls[0]).SendHeartbeat("NOT-FOUND", "NOT-FOUND")
-    if err != nil {
-        t.Log("NOT-FOUND-TEST: ", err.Error())
+    err := eureka.NewEurekaServerApi(server.ServiceUrl()).SendHeartbeat("NOT-FOUND", "NOT-FOUND")
+    if err == nil {
+        t.Fatal("Heartbeat of instance not registered should fail")
     }
+    t.Log("NOT-FOUND-TEST: ", err.Error())
 
     // TEST APP
-    err = NewEurekaServerApi(urls[0]).SendHeartbeat(test_app_name, genDefaultInstanceId(test_app_name, test_instance_port))
+    err = eureka.NewEurekaServerApi(server.ServiceUrl()).SendHeartbeat(test_app_name, instanceId)
     if err != nil {
-        t.Log("TEST heartbeat, app=", test_app_name, ", err=", err.Error())
+        t.Fatal("TEST heartbeat, app=", test_app_name, ", err=", err.Error())
     }
 
     t.Log("SUCCESS")
 }
 
 func Test_UpdateInstanceStatus(t *testing.T) {
-    config := getTestDnsEurekaConfig()
-    endpointUtils := new(EndpointUtils)
-    urls, err := endpointUtils.GetDiscoveryServiceUrls(config, "zone-cn-hz-1")
+    server, instanceId := startTestServer(t)
+    defer server.Close()
+
+    err := eureka.NewEurekaServerApi(server.ServiceUrl()).UpdateInstanceStatus(test_app_name, instanceId, eureka.STATUS_UP)
     if err != nil {
         t.Fatal(err.Error())
     }
+    if status := server.Instances(test_app_name)[0].Status; status != eureka.STATUS_UP {
+        t.Fatalf("Expected status UP, got %s", status)
+    }
+
+    t.Log("Success to update instance status")
+}
 
-    t.Log("Eureka server url: ", urls[0])
+func Test_QueryAllInstanceByAppId(t *testing.T) {
+    server, instanceId := startTestServer(t)
+    defer server.Close()
 
-    // 1.1 register instance
-    instanceId, err := NewEurekaServerApi(urls[0]).RegisterInstance(test_app_name, test_instance_port)
+    instances, err := eureka.NewEurekaServerApi(server.ServiceUrl()).QueryAllInstanceByAppId(test_app_name)
     if err != nil {
-        t.Error("Failed to register app: ", err.Error())
+        t.Fatal("Failed to query app instances: ", err.Error())
     }
+    if len(instances) != 1 || instances[0].InstanceId != instanceId {
+        t.Fatalf("Expected instance %s, got %v", instanceId, instances)
+    }
+
+    t.Log(test_app_name+" instances: ", instances)
+}
+
+func Test_QuerySpecificAppInstance(t *testing.T) {
+    server, instanceId := startTestServer(t)
+    defer server.Close()
 
-    // 1.2 update instance's status
-    err = NewEurekaServerApi(urls[0]).UpdateInstanceStatus(test_app_name, instanceId, STATUS_UP)
+    instance, err := eureka.NewEurekaServerApi(server.ServiceUrl()).QuerySpecificAppInstance(instanceId)
     if err != nil {
         t.Fatal(err.Error())
     }
+    if instance.InstanceId != instanceId {
+        t.Fatalf("Expected instance %s, got %s", instanceId, instance.InstanceId)
+    }
 
-    t.Log("Success to update instance status")
+    t.Log("instance: ", instance)
 }
 
-func Test_QueryAllInstanceByAppId(t *testing.T) {
-    config := getTestDnsEurekaConfig()
-    endpointUtils := new(EndpointUtils)
-    urls, err := endpointUtils.GetDiscoveryServiceUrls(config, "zone-cn-hz-1")
+func Test_UpdateMeta(t *testing.T) {
+    server, instanceId := startTestServer(t)
+    defer server.Close()
+
+    err := eureka.NewEurekaServerApi(server.ServiceUrl()).UpdateMeta(test_app_name, instanceId,
+        map[string]string{
+            "key": "value",
+        })
     if err != nil {
         t.Fatal(err.Error())
     }
+    if value := server.Instances(test_app_name)[0].Metadata["key"]; value != "value" {
+        t.Fatalf("Expected metadata key=value, got %s", value)
+    }
 
-    t.Log("Eureka server url: ", urls[0])
+    t.Log("Succeed to update meta.")
+}
 
-    instances, err := NewEurekaServerApi(urls[0]).QueryAllInstanceByAppId(test_app_name)
+func Test_TLSServer(t *testing.T) {
+    server := eurekatest.NewTLSServer()
+    defer server.Close()
+
+    api := eureka.NewEurekaServerApi(server.ServiceUrl(), eureka.WithHttpClient(server.Client()))
+    _, err := api.RegisterInstance(test_app_name, test_instance_port)
     if err != nil {
-        t.Fatal("Failed to register app: ", err.Error())
+        t.Fatal("Failed to register app over https: ", err.Error())
     }
 
-    t.Log(test_app_name+" instances: ", instances)
+    // server certificate is not trusted by default http client
+    _, err = eureka.NewEurekaServerApi(server.ServiceUrl()).QueryAllInstances()
+    if err == nil {
+        t.Fatal("Request with untrusted server certificate should fail")
+    }
 }
 
-func Test_QuerySpecificAppInstance(t *testing.T) {
-    config := getTestDnsEurekaConfig()
-    endpointUtils := new(EndpointUtils)
-    urls, err := endpointUtils.GetDiscoveryServiceUrls(config, "zone-cn-hz-1")
+func Test_BasicAuthAndHeaders(t *testing.T) {
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        username, password, ok := r.BasicAuth()
+        if !ok || username != "user" || password != "pass" {
+            w.WriteHeader(http.StatusUnauthorized)
+            return
+        }
+        if r.Header.Get("X-Custom-Header") != "custom" {
+            w.WriteHeader(http.StatusBadRequest)
+            return
+        }
+        w.WriteHeader(http.StatusOK)
+    }))
+    defer server.Close()
+
+    // credentials embedded in service url
+    serviceUrl := strings.Replace(server.URL, "http://", "http://user:pass@", 1) + "/eureka"
+    api := eureka.NewEurekaServerApi(serviceUrl, eureka.WithHeaders(map[string]string{"X-Custom-Header": "custom"}))
+    if strings.Contains(api.BaseUrl, "pass") {
+        t.Fatalf("Credentials should be stripped from base url, got %s", api.BaseUrl)
+    }
+    err := api.SendHeartbeat(test_app_name, "instance-id")
     if err != nil {
         t.Fatal(err.Error())
     }
 
-    t.Log("Eureka server url: ", urls[0])
+    // wrong credentials
+    api = eureka.NewEurekaServerApi(server.URL+"/eureka", eureka.WithBasicAuth("user", "wrong"))
+    err = api.SendHeartbeat(test_app_name, "instance-id")
+    if err == nil {
+        t.Fatal("Request with wrong credentials should fail")
+    }
+}
+
+func Test_XmlCodec(t *testing.T) {
+    server := eurekatest.NewServer()
+    defer server.Close()
+
+    api := eureka.NewEurekaServerApi(server.ServiceUrl(), eureka.WithCodec(eureka.GetCodec(eureka.CODEC_XML)))
+    vo := eureka.DefaultInstanceVo()
+    vo.App = test_app_name
+    vo.Metadata = map[string]string{"version": "1.0"}
+    instanceId, err := api.RegisterInstanceWithVo(vo)
+    if err != nil {
+        t.Fatal("Failed to register app with XML: ", err.Error())
+    }
 
-    instance, err := NewEurekaServerApi(urls[0]).QuerySpecificAppInstance(genDefaultInstanceId(test_app_name, test_instance_port))
+    instances, err := api.QueryAllInstanceByAppId(test_app_name)
     if err != nil {
         t.Fatal(err.Error())
     }
+    if len(instances) != 1 || instances[0].InstanceId != instanceId || instances[0].Metadata["version"] != "1.0" {
+        t.Fatalf("Unexpected instances: %v", instances)
+    }
+}
 
-    t.Log("instance: ", instance)
+func Test_TypedErrors(t *testing.T) {
+    server := eurekatest.NewServer()
+    defer server.Close()
+
+    // http error
+    err := eureka.NewEurekaServerApi(server.ServiceUrl()).SendHeartbeat("NOT-FOUND", "NOT-FOUND")
+    var httpErr *eureka.HTTPError
+    if !errors.Is(err, eureka.ErrNotFound) || !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusNotFound {
+        t.Fatalf("Expected not found http error, got %v", err)
+    }
+
+    // transport error
+    _, err = eureka.NewEurekaServerApi("http://127.0.0.1:1/eureka").QueryAllInstances()
+    var transportErr *eureka.TransportError
+    if !errors.As(err, &transportErr) || errors.Is(err, eureka.ErrNotFound) {
+        t.Fatalf("Expected transport error, got %v", err)
+    }
+
+    // decode error
+    malformed := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        w.Header().Set("Content-Type", eureka.CONTENT_TYPE_JSON)
+        w.Write([]byte("{malformed"))
+    }))
+    defer malformed.Close()
+
+    _, err = eureka.NewEurekaServerApi(malformed.URL + "/eureka").QueryAllInstances()
+    var decodeErr *eureka.DecodeError
+    if !errors.As(err, &decodeErr) {
+        t.Fatalf("Expected decode error, got %v", err)
+    }
 }
 
-func Test_UpdateMeta(t *testing.T) {
-    config := getTestDnsEurekaConfig()
-    endpointUtils := new(EndpointUtils)
-    urls, err := endpointUtils.GetDiscoveryServiceUrls(config, "zone-cn-hz-1")
+func Test_RequestWithContext(t *testing.T) {
+    slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        select {
+        case <-r.Context().Done():
+        case <-time.After(5 * time.Second):
+        }
+    }))
+    defer slow.Close()
+
+    ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+    defer cancel()
+
+    start := time.Now()
+    err := eureka.NewEurekaServerApi(slow.URL+"/eureka").SendHeartbeatContext(ctx, test_app_name, "instance-id")
+    if !errors.Is(err, context.DeadlineExceeded) {
+        t.Fatalf("Expected deadline exceeded, got %v", err)
+    }
+    if time.Since(start) > 2*time.Second {
+        t.Fatal("Request should be canceled by context")
+    }
+}
+
+func Test_QueryAllVipInstances(t *testing.T) {
+    server, instanceId := startTestServer(t)
+    defer server.Close()
+
+    api := eureka.NewEurekaServerApi(server.ServiceUrl())
+    vo := eureka.DefaultInstanceVo()
+    vo.App = "test-other-app"
+    vo.VipAddress = "other-vip"
+    vo.SecureVipAddress = "other-svip"
+    _, err := api.RegisterInstanceWithVo(vo)
     if err != nil {
         t.Fatal(err.Error())
     }
 
-    t.Log("Eureka server url: ", urls[0])
+    apps, err := api.QueryAllVipInstances(eureka.DefaultInstanceVo().VipAddress)
+    if err != nil {
+        t.Fatal(err.Error())
+    }
+    if len(apps) != 1 || len(apps[0].Instances) != 1 || apps[0].Instances[0].InstanceId != instanceId {
+        t.Fatalf("Expected instance %s, got %v", instanceId, apps)
+    }
 
-    err = NewEurekaServerApi(urls[0]).UpdateMeta(test_app_name,
-        genDefaultInstanceId(test_app_name, test_instance_port),
-        map[string]string{
-            "key": "value",
-        })
+    apps, err = api.QueryAllSVipInstances("other-svip")
     if err != nil {
         t.Fatal(err.Error())
     }
+    if len(apps) != 1 || apps[0].Instances[0].InstanceId != vo.InstanceId {
+        t.Fatalf("Expected instance %s, got %v", vo.InstanceId, apps)
+    }
+}
 
-    t.Log("Succeed to update meta.")
+// value of metric series in Prometheus text format, -1 while not found
+func metricValue(metrics *eureka.PrometheusMetrics, series string) float64 {
+    for _, line := range strings.Split(metrics.String(), "\n") {
+        if strings.HasPrefix(line, series+" ") {
+            value, _ := strconv.ParseFloat(strings.TrimPrefix(line, series+" "), 64)
+            return value
+        }
+    }
+
+    return -1
+}
+
+func Test_GZipContent(t *testing.T) {
+    server, _ := startTestServer(t)
+    defer server.Close()
+
+    for _, gzip := range []bool{true, false} {
+        metrics := eureka.NewPrometheusMetrics()
+        api := eureka.NewEurekaServerApi(server.ServiceUrl(), eureka.WithGZipContent(gzip), eureka.WithMetrics(metrics))
+        applications, err := api.QueryAllInstances()
+        if err != nil {
+            t.Fatal(err.Error())
+        }
+        if len(applications) != 1 || len(applications[0].Instances) != 1 {
+            t.Fatalf("Expected 1 application with 1 instance, got %v", applications)
+        }
+
+        encoding := "identity"
+        if gzip {
+            encoding = "gzip"
+        }
+        received := metricValue(metrics, `eureka_client_api_response_bytes_total{encoding="`+encoding+`",operation="query_apps"}`)
+        decoded := metricValue(metrics, `eureka_client_api_response_decoded_bytes_total{operation="query_apps"}`)
+        if received <= 0 || decoded <= 0 || (gzip && received >= decoded) || (!gzip && received != decoded) {
+            t.Fatalf("Unexpected bytes, gzip=%t, received=%v, decoded=%v:\n%s", gzip, received, decoded, metrics.String())
+        }
+    }
 }
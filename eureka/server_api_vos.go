@@ -1,5 +1,12 @@
 package eureka
 
+import (
+    "fmt"
+    "strconv"
+    "strings"
+    "time"
+)
+
 const (
     STATUS_UP             = "UP"
     STATUS_DOWN           = "DOWN"
@@ -9,64 +16,140 @@ const (
 
     DC_NAME_TYPE_MY_OWN = "MyOwn"
     DC_NAME_TYPE_AMAZON = "Amazon"
+
+    // keys of data center metadata of AWS EC2 instance
+    // Refer to: com.netflix.appinfo.AmazonInfo.MetaDataKey
+    AMAZON_METADATA_AMI_LAUNCH_INDEX  = "ami-launch-index"
+    AMAZON_METADATA_LOCAL_HOSTNAME    = "local-hostname"
+    AMAZON_METADATA_AVAILABILITY_ZONE = "availability-zone"
+    AMAZON_METADATA_INSTANCE_ID       = "instance-id"
+    AMAZON_METADATA_PUBLIC_IPV4       = "public-ipv4"
+    AMAZON_METADATA_PUBLIC_HOSTNAME   = "public-hostname"
+    AMAZON_METADATA_AMI_MANIFEST_PATH = "ami-manifest-path"
+    AMAZON_METADATA_LOCAL_IPV4        = "local-ipv4"
+    AMAZON_METADATA_HOSTNAME          = "hostname"
+    AMAZON_METADATA_AMI_ID            = "ami-id"
+    AMAZON_METADATA_INSTANCE_TYPE     = "instance-type"
+    AMAZON_METADATA_MAC               = "mac"
+    AMAZON_METADATA_VPC_ID            = "vpc-id"
+    AMAZON_METADATA_ACCOUNT_ID        = "accountId"
+
+    // instance action type in delta registry (/apps/delta)
+    ACTION_TYPE_ADDED    = "ADDED"
+    ACTION_TYPE_MODIFIED = "MODIFIED"
+    ACTION_TYPE_DELETED  = "DELETED"
 )
 
 type (
     InstanceVo struct {
         // Register application instance needed -- BEGIN
-        Hostname         string         `json:"hostName"`
-        App              string         `json:"app"`
-        IppAddr          string         `json:"ipAddr"`
-        VipAddress       string         `json:"vipAddress"`
-        SecureVipAddress string         `json:"secureVipAddress"`
-        Status           string         `json:"status"`
-        Port             positiveInt    `json:"port"`
-        SecurePort       positiveInt    `json:"securePort"`
-        HomePageUrl      string         `json:"homePageUrl"`
-        StatusPageUrl    string         `json:"statusPageUrl"`
-        HealthCheckUrl   string         `json:"healthCheckUrl"`
-        DataCenterInfo   DataCenterInfo `json:"dataCenterInfo"`
-        LeaseInfo        LeaseInfo      `json:"leaseInfo"`
+        Hostname         string         `json:"hostName" xml:"hostName"`
+        App              string         `json:"app" xml:"app"`
+        IppAddr          string         `json:"ipAddr" xml:"ipAddr"`
+        VipAddress       string         `json:"vipAddress" xml:"vipAddress"`
+        SecureVipAddress string         `json:"secureVipAddress" xml:"secureVipAddress"`
+        Status           string         `json:"status" xml:"status"`
+        Port             positiveInt    `json:"port" xml:"port"`
+        SecurePort       positiveInt    `json:"securePort" xml:"securePort"`
+        HomePageUrl      string         `json:"homePageUrl" xml:"homePageUrl"`
+        StatusPageUrl    string         `json:"statusPageUrl" xml:"statusPageUrl"`
+        HealthCheckUrl   string         `json:"healthCheckUrl" xml:"healthCheckUrl"`
+        DataCenterInfo   DataCenterInfo `json:"dataCenterInfo" xml:"dataCenterInfo"`
+        LeaseInfo        LeaseInfo      `json:"leaseInfo" xml:"leaseInfo"`
         // Register application instance needed -- END
 
-        InstanceId           string `json:"instanceId,omitempty"`
-        OverriddenStatus     string `json:"overriddenstatus,omitempty"`
-        LastUpdatedTimestamp int    `json:"lastUpdatedTimestamp,omitempty"`
-        LastDirtyTimestamp   int    `json:"lastUpdatedTimestamp,omitempty"`
-        ActionType           string `json:"actionType,omitempty"`
+        Metadata InstanceMetadata `json:"metadata,omitempty" xml:"metadata,omitempty"`
+
+        InstanceId           string `json:"instanceId,omitempty" xml:"instanceId,omitempty"`
+        SecureHealthCheckUrl string `json:"secureHealthCheckUrl,omitempty" xml:"secureHealthCheckUrl,omitempty"`
+        AppGroupName         string `json:"appGroupName,omitempty" xml:"appGroupName,omitempty"`
+        ASGName              string `json:"asgName,omitempty" xml:"asgName,omitempty"`
+        CountryId            int    `json:"countryId,omitempty" xml:"countryId,omitempty"`
+        // true|false
+        IsCoordinatingDiscoveryServer string    `json:"isCoordinatingDiscoveryServer,omitempty" xml:"isCoordinatingDiscoveryServer,omitempty"`
+        OverriddenStatus              string    `json:"overriddenstatus,omitempty" xml:"overriddenstatus,omitempty"`
+        LastUpdatedTimestamp          Timestamp `json:"lastUpdatedTimestamp,omitempty" xml:"lastUpdatedTimestamp,omitempty"`
+        LastDirtyTimestamp            Timestamp `json:"lastDirtyTimestamp,omitempty" xml:"lastDirtyTimestamp,omitempty"`
+        ActionType                    string    `json:"actionType,omitempty" xml:"actionType,omitempty"`
     }
 
     positiveInt struct {
-        Value   int    `json:"$"`
-        Enabled string `json:"@enabled"` // true|false
+        Value   int    `json:"$" xml:",chardata"`
+        Enabled string `json:"@enabled" xml:"enabled,attr"` // true|false
     }
 
     DataCenterInfo struct {
         // MyOwn | Amazon
-        Name string `json:"name"`
-        // metadata is only required if name is Amazon
-        Metadata string `json:"metadata,omitempty"`
-        Class    string `json:"@class"`
+        Name string `json:"name" xml:"name"`
+        // metadata is only required if name is Amazon, keys: AMAZON_METADATA_*,
+        // unknown keys are kept as they are
+        Metadata InstanceMetadata `json:"metadata,omitempty" xml:"metadata,omitempty"`
+        Class    string           `json:"@class" xml:"class,attr"`
     }
 
     LeaseInfo struct {
+        // (optional) how often client sends heartbeat - default is 30 seconds
+        RenewalIntervalInSecs int `json:"renewalIntervalInSecs,omitempty" xml:"renewalIntervalInSecs,omitempty"`
         // (optional) if you want to change the length of lease - default if 90 seconds
-        EvictionDurationInSecs int `json:"eviction_duration_in_secs,omitempty"`
+        EvictionDurationInSecs int `json:"durationInSecs,omitempty" xml:"durationInSecs,omitempty"`
+
+        // set by eureka server
+        RegistrationTimestamp Timestamp `json:"registrationTimestamp,omitempty" xml:"registrationTimestamp,omitempty"`
+        LastRenewalTimestamp  Timestamp `json:"lastRenewalTimestamp,omitempty" xml:"lastRenewalTimestamp,omitempty"`
+        EvictionTimestamp     Timestamp `json:"evictionTimestamp,omitempty" xml:"evictionTimestamp,omitempty"`
+        ServiceUpTimestamp    Timestamp `json:"serviceUpTimestamp,omitempty" xml:"serviceUpTimestamp,omitempty"`
     }
 
     // application
     ApplicationVo struct {
-        Name      string       `json:"name"`
-        Instances []InstanceVo `json:"instance"`
+        Name      string       `json:"name" xml:"name"`
+        Instances []InstanceVo `json:"instance" xml:"instance"`
     }
 
     ApplicationsVo struct {
-        VersionDelta string          `json:"version__delta"`
-        AppsHashCode string          `json:"apps_hash__code"`
-        Application  []ApplicationVo `json:"application"`
+        VersionDelta string          `json:"versions__delta" xml:"versions__delta"`
+        AppsHashCode string          `json:"apps__hashcode" xml:"apps__hashcode"`
+        Application  []ApplicationVo `json:"application" xml:"application"`
     }
 )
 
+// unix timestamp in milliseconds, eureka server sends it as number or string, e.g: "1581000000000"
+type Timestamp int64
+
+func (t Timestamp) MarshalJSON() ([]byte, error) {
+    return []byte(strconv.FormatInt(int64(t), 10)), nil
+}
+
+func (t *Timestamp) UnmarshalJSON(data []byte) error {
+    str := strings.Trim(string(data), "\"")
+    if str == "" || str == "null" {
+        *t = 0
+        return nil
+    }
+
+    v, err := strconv.ParseInt(str, 10, 64)
+    if err != nil {
+        return err
+    }
+    *t = Timestamp(v)
+    return nil
+}
+
+// time of timestamp
+func (t Timestamp) Time() time.Time {
+    return time.Unix(0, int64(t)*int64(time.Millisecond))
+}
+
+// base url of instance, e.g: http://192.168.20.1:8080,
+// use https and secure port while secure port is enabled
+func (t *InstanceVo) BaseUrl() string {
+    if t.SecurePort.Enabled == "true" {
+        return fmt.Sprintf("https://%s:%d", t.IppAddr, t.SecurePort.Value)
+    }
+
+    return fmt.Sprintf("http://%s:%d", t.IppAddr, t.Port.Value)
+}
+
 func DefaultInstanceVo() *InstanceVo {
     ip := getLocalIp()
     //hostname, err := os.Hostname()
@@ -92,7 +175,8 @@ func DefaultInstanceVo() *InstanceVo {
             Name:  DC_NAME_TYPE_MY_OWN,
         },
         LeaseInfo: LeaseInfo{
-            EvictionDurationInSecs: 30,
+            RenewalIntervalInSecs:  30,
+            EvictionDurationInSecs: 90,
         },
     }
 }
@@ -0,0 +1,232 @@
+package eureka
+
+import (
+    "bufio"
+    "compress/gzip"
+    "crypto/tls"
+    "crypto/x509"
+    "errors"
+    "io"
+    "io/ioutil"
+    "net"
+    "net/http"
+    "strings"
+    "sync"
+    "time"
+)
+
+const (
+    DEFAULT_EUREKA_SERVER_READ_TIMEOUT_SECONDS       = 8
+    DEFAULT_EUREKA_SERVER_CONNECT_TIMEOUT_SECONDS    = 5
+    DEFAULT_EUREKA_SERVER_TOTAL_CONNECTIONS          = 200
+    DEFAULT_EUREKA_SERVER_TOTAL_CONNECTIONS_PER_HOST = 50
+    DEFAULT_EUREKA_CONNECTION_IDLE_TIMEOUT_SECONDS   = 30
+)
+
+// whether TLS is configured to talk to eureka server
+func (t *EurekaClientConfig) tlsEnabled() bool {
+    return t.TLSCAFile != "" || t.TLSCertFile != "" || t.TLSKeyFile != "" || t.TLSInsecureSkipVerify
+}
+
+// build TLS config from TLSCAFile, TLSCertFile, TLSKeyFile and TLSInsecureSkipVerify
+func newTLSConfig(config *EurekaClientConfig) (*tls.Config, error) {
+    tlsConfig := &tls.Config{
+        InsecureSkipVerify: config.TLSInsecureSkipVerify,
+    }
+
+    if config.TLSCAFile != "" {
+        ca, err := ioutil.ReadFile(config.TLSCAFile)
+        if err != nil {
+            log.Errorf("Failed to read TLS CA file=%s, err=%s", config.TLSCAFile, err.Error())
+            return nil, err
+        }
+
+        pool := x509.NewCertPool()
+        if !pool.AppendCertsFromPEM(ca) {
+            return nil, errors.New("Failed to append certificates from TLS CA file: " + config.TLSCAFile)
+        }
+        tlsConfig.RootCAs = pool
+    }
+
+    // client certificate for mTLS
+    if config.TLSCertFile != "" || config.TLSKeyFile != "" {
+        cert, err := tls.LoadX509KeyPair(config.TLSCertFile, config.TLSKeyFile)
+        if err != nil {
+            log.Errorf("Failed to load TLS client certificate, cert=%s, key=%s, err=%s", config.TLSCertFile, config.TLSKeyFile, err.Error())
+            return nil, err
+        }
+        tlsConfig.Certificates = []tls.Certificate{cert}
+    }
+
+    return tlsConfig, nil
+}
+
+// build http client to talk to eureka server from config, with pooled transport
+// (shared by all requests of a Client, refer to Client.apiOptions()):
+// connections are dialed within EurekaServerConnectTimeoutSeconds, response headers are waited for
+// EurekaServerReadTimeoutSeconds, and the whole request is bounded by both.
+// Up to EurekaServerTotalConnectionsPerHost connections are opened to each eureka server, and idle ones
+// are kept up to EurekaServerTotalConnections (across eureka servers) for EurekaConnectionIdleTimeoutSeconds.
+// Fields of 0 fall back to defaults
+func newHttpClient(config *EurekaClientConfig) (*http.Client, error) {
+    connectTimeout := time.Second * time.Duration(positiveOrDefault(config.EurekaServerConnectTimeoutSeconds, DEFAULT_EUREKA_SERVER_CONNECT_TIMEOUT_SECONDS))
+    readTimeout := time.Second * time.Duration(positiveOrDefault(config.EurekaServerReadTimeoutSeconds, DEFAULT_EUREKA_SERVER_READ_TIMEOUT_SECONDS))
+    idleTimeout := time.Second * time.Duration(positiveOrDefault(config.EurekaConnectionIdleTimeoutSeconds, DEFAULT_EUREKA_CONNECTION_IDLE_TIMEOUT_SECONDS))
+
+    transport := &http.Transport{
+        Proxy: http.ProxyFromEnvironment,
+        DialContext: (&net.Dialer{
+            Timeout:   connectTimeout,
+            KeepAlive: 30 * time.Second,
+        }).DialContext,
+        TLSHandshakeTimeout:   connectTimeout,
+        ResponseHeaderTimeout: readTimeout,
+        MaxIdleConns:          config.getTotalConnections(),
+        MaxIdleConnsPerHost:   config.getTotalConnectionsPerHost(),
+        MaxConnsPerHost:       config.getTotalConnectionsPerHost(),
+        IdleConnTimeout:       idleTimeout,
+    }
+
+    if config.tlsEnabled() {
+        tlsConfig, err := newTLSConfig(config)
+        if err != nil {
+            return nil, err
+        }
+        transport.TLSClientConfig = tlsConfig
+    }
+
+    return &http.Client{
+        Timeout:   connectTimeout + readTimeout,
+        Transport: transport,
+    }, nil
+}
+
+// EurekaServerTotalConnections, DEFAULT_EUREKA_SERVER_TOTAL_CONNECTIONS while 0
+func (t *EurekaClientConfig) getTotalConnections() int {
+    return positiveOrDefault(t.EurekaServerTotalConnections, DEFAULT_EUREKA_SERVER_TOTAL_CONNECTIONS)
+}
+
+// EurekaServerTotalConnectionsPerHost, DEFAULT_EUREKA_SERVER_TOTAL_CONNECTIONS_PER_HOST while 0
+func (t *EurekaClientConfig) getTotalConnectionsPerHost() int {
+    return positiveOrDefault(t.EurekaServerTotalConnectionsPerHost, DEFAULT_EUREKA_SERVER_TOTAL_CONNECTIONS_PER_HOST)
+}
+
+func positiveOrDefault(value, defaultValue int) int {
+    if value > 0 {
+        return value
+    }
+    return defaultValue
+}
+
+// http transport negotiating content encoding of response (Accept-Encoding: gzip | identity),
+// decompressing gzip body, and reporting bytes of response body received and decoded
+type encodingTransport struct {
+    base http.RoundTripper
+
+    // gzip | identity, "" leaves Accept-Encoding to base transport
+    acceptEncoding string
+
+    // labels of metrics
+    operation string
+    metrics   Metrics
+}
+
+func (t *encodingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+    if t.acceptEncoding != "" {
+        // RoundTripper should not modify request
+        req = req.Clone(req.Context())
+        req.Header.Set("Accept-Encoding", t.acceptEncoding)
+    }
+
+    res, err := t.base.RoundTrip(req)
+    if err != nil || res.Body == nil || res.Body == http.NoBody {
+        return res, err
+    }
+
+    body := &countingBody{raw: res.Body, operation: t.operation, encoding: "identity", metrics: t.metrics}
+    body.received.r = res.Body
+
+    // (base transport decompresses body itself while Accept-Encoding is not set explicitly,
+    // bytes received are decompressed ones then)
+    if strings.ToLower(res.Header.Get("Content-Encoding")) == "gzip" {
+        body.encoding = "gzip"
+
+        res.Header.Del("Content-Encoding")
+        res.Header.Del("Content-Length")
+        res.ContentLength = -1
+        res.Uncompressed = true
+    }
+    res.Body = body
+
+    return res, nil
+}
+
+// reader counting bytes read
+type countingReader struct {
+    r io.Reader
+    n int64
+}
+
+func (t *countingReader) Read(p []byte) (int, error) {
+    n, err := t.r.Read(p)
+    t.n += int64(n)
+    return n, err
+}
+
+// response body decompressed (gzip reader is created on first read), reporting bytes received and decoded on close
+type countingBody struct {
+    raw      io.ReadCloser
+    received countingReader
+    reader   io.Reader
+    err      error
+    decoded  int64
+
+    operation string
+    encoding  string
+    metrics   Metrics
+    closeOnce sync.Once
+}
+
+func (t *countingBody) Read(p []byte) (int, error) {
+    if t.reader == nil && t.err == nil {
+        t.reader, t.err = t.newReader()
+    }
+    if t.err != nil {
+        return 0, t.err
+    }
+
+    n, err := t.reader.Read(p)
+    t.decoded += int64(n)
+    return n, err
+}
+
+// gzip reader while body starts with gzip magic number,
+// otherwise body is read as it is, e.g: empty body, or error page of proxy
+func (t *countingBody) newReader() (io.Reader, error) {
+    if t.encoding != "gzip" {
+        return &t.received, nil
+    }
+
+    br := bufio.NewReader(&t.received)
+    magic, _ := br.Peek(2)
+    if len(magic) < 2 || magic[0] != 0x1f || magic[1] != 0x8b {
+        t.encoding = "identity"
+        return br, nil
+    }
+
+    return gzip.NewReader(br)
+}
+
+func (t *countingBody) Close() error {
+    err := t.raw.Close()
+    t.closeOnce.Do(func() {
+        adder, ok := t.metrics.(CounterAdder)
+        if !ok {
+            return
+        }
+        adder.AddCounter(METRIC_API_RESPONSE_BYTES_TOTAL, map[string]string{"operation": t.operation, "encoding": t.encoding}, float64(t.received.n))
+        adder.AddCounter(METRIC_API_RESPONSE_DECODED_BYTES_TOTAL, map[string]string{"operation": t.operation}, float64(t.decoded))
+    })
+
+    return err
+}
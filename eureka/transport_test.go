@@ -0,0 +1,99 @@
+package eureka
+
+import (
+    "errors"
+    "net"
+    "net/http"
+    "net/http/httptest"
+    "sync/atomic"
+    "testing"
+    "time"
+)
+
+func Test_NewHttpClient(t *testing.T) {
+    config := GetDefaultEurekaClientConfig()
+    config.EurekaServerTotalConnectionsPerHost = 2
+    httpClient, err := newHttpClient(config)
+    if err != nil {
+        t.Fatal(err.Error())
+    }
+
+    transport, ok := httpClient.Transport.(*http.Transport)
+    if !ok {
+        t.Fatalf("Unexpected transport: %T", httpClient.Transport)
+    }
+    if transport.MaxConnsPerHost != 2 || transport.MaxIdleConns != 200 || transport.ResponseHeaderTimeout != 8*time.Second ||
+        transport.IdleConnTimeout != 30*time.Second || httpClient.Timeout != 13*time.Second {
+        t.Fatalf("Unexpected http client, timeout=%s, transport=%+v", httpClient.Timeout, transport)
+    }
+
+    // connections are reused across requests
+    var conns int32
+    server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        w.Header().Set("Content-Type", "application/json")
+        w.Write([]byte(`{"applications": {"application": []}}`))
+    }))
+    server.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+        if state == http.StateNew {
+            atomic.AddInt32(&conns, 1)
+        }
+    }
+    server.Start()
+    defer server.Close()
+
+    for i := 0; i < 3; i++ {
+        api := NewEurekaServerApi(server.URL, WithHttpClient(httpClient), WithGZipContent(true))
+        _, err := api.QueryAllApplications()
+        if err != nil {
+            t.Fatal(err.Error())
+        }
+    }
+    if n := atomic.LoadInt32(&conns); n != 1 {
+        t.Fatalf("Expected 1 pooled connection, got %d", n)
+    }
+}
+
+func Test_NewHttpClientDefaults(t *testing.T) {
+    // config built without GetDefaultEurekaClientConfig(), zero value of timeouts and connections
+    config := &EurekaClientConfig{}
+
+    httpClient, err := newHttpClient(config)
+    if err != nil {
+        t.Fatal(err.Error())
+    }
+    transport := httpClient.Transport.(*http.Transport)
+    if transport.MaxConnsPerHost != 50 || transport.MaxIdleConns != 200 || transport.ResponseHeaderTimeout != 8*time.Second ||
+        transport.IdleConnTimeout != 30*time.Second || httpClient.Timeout != 13*time.Second {
+        t.Fatalf("Expected defaults, timeout=%s, transport=%+v", httpClient.Timeout, transport)
+    }
+}
+
+func Test_EncodingTransportLazyGzip(t *testing.T) {
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        w.Header().Set("Content-Encoding", "gzip")
+        w.Header().Set("Content-Type", "application/json")
+        if r.URL.Path == "/eureka/apps" {
+            // chunked, empty body
+            w.WriteHeader(http.StatusOK)
+            w.(http.Flusher).Flush()
+            return
+        }
+        w.WriteHeader(http.StatusServiceUnavailable)
+        w.Write([]byte("Service Unavailable"))
+    }))
+    defer server.Close()
+
+    api := NewEurekaServerApi(server.URL+"/eureka", WithGZipContent(true))
+    _, err := api.QueryAllInstances()
+    var decodeErr *DecodeError
+    if !errors.As(err, &decodeErr) {
+        t.Fatalf("Empty body should be decoded (and failed), got %v", err)
+    }
+
+    // body isn't gzip actually
+    _, err = api.QueryAllInstanceByAppId("APP")
+    var httpErr *HTTPError
+    if !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusServiceUnavailable || httpErr.Body != "Service Unavailable" {
+        t.Fatalf("Expected HTTPError of 503, got %v", err)
+    }
+}
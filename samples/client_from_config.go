@@ -1,7 +1,13 @@
 package main
 
 import (
+    "context"
+    "fmt"
     "github.com/HikoQiu/go-eureka-client/eureka"
+    "os"
+    "os/signal"
+    "syscall"
+    "time"
 )
 
 func main() {
@@ -25,9 +31,20 @@ func main() {
     //})
 
     // run eureka client async
-    eureka.DefaultClient.Config(config).
-        Register("APP_ID_CLIENT_FROM_CONFIG", 9000).
-        Run()
+    client := eureka.DefaultClient.Config(config).
+        Register("APP_ID_CLIENT_FROM_CONFIG", 9000)
+    err := client.Run()
+    if err != nil {
+        fmt.Println("Failed to run eureka client, err=" + err.Error())
+        os.Exit(1)
+    }
+
+    // wait for exit signal, then shutdown client (de-register instance)
+    sigChan := make(chan os.Signal, 1)
+    signal.Notify(sigChan, syscall.SIGTERM, syscall.SIGINT)
+    <-sigChan
 
-    select {}
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+    client.Shutdown(ctx)
 }
@@ -1,7 +1,9 @@
 package main
 
 import (
+    "fmt"
     "github.com/HikoQiu/go-eureka-client/eureka"
+    "os"
 )
 
 func main() {
@@ -15,6 +17,8 @@ func main() {
     config.EurekaServerDNSName = "dev.ms-registry.xf.io"
     config.EurekaServerUrlContext = "eureka"
     config.EurekaServerPort = "9001"
+    // de-register instance and exit process on SIGTERM/SIGINT
+    config.HandleExitSignal = true
 
     // custom logger
     //eureka.SetLogger(func(level int, format string, a ...interface{}) {
@@ -26,9 +30,13 @@ func main() {
     //})
 
     // run eureka client async
-    eureka.DefaultClient.Config(config).
+    err := eureka.DefaultClient.Config(config).
         Register("APP_ID_CLIENT_FROM_DNS", 9000).
         Run()
+    if err != nil {
+        fmt.Println("Failed to run eureka client, err=" + err.Error())
+        os.Exit(1)
+    }
 
     select {}
 }